@@ -0,0 +1,25 @@
+package s3lock
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3API is the subset of *s3.Client used by s3lock.
+// It can be implemented by middleware-wrapped clients, caching layers or test doubles.
+// Errors of failed conditional requests must implement "HTTPStatusCode() int"
+// (e.g. 404 Not Found, 412 Precondition Failed) like *awshttp.ResponseError.
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// S3ListAPI is S3API that can also list objects.
+type S3ListAPI interface {
+	S3API
+	s3.ListObjectsV2APIClient
+}
+
+var _ S3ListAPI = (*s3.Client)(nil)
@@ -0,0 +1,162 @@
+package s3lock_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+)
+
+type testS3Error int
+
+func (e testS3Error) Error() string {
+	return http.StatusText(int(e))
+}
+
+func (e testS3Error) HTTPStatusCode() int {
+	return int(e)
+}
+
+type testS3Object struct {
+	body         []byte
+	etag         string
+	lastModified time.Time
+}
+
+// testS3API is a test double of S3API that honours If-Match/If-None-Match.
+type testS3API struct {
+	mu      sync.Mutex
+	objects map[string]*testS3Object
+}
+
+func (api *testS3API) get(key string, ifMatch *string) (*testS3Object, error) {
+	obj, ok := api.objects[key]
+
+	if !ok {
+		return nil, testS3Error(http.StatusNotFound)
+	}
+
+	if ifMatch != nil && *ifMatch != obj.etag {
+		return nil, testS3Error(http.StatusPreconditionFailed)
+	}
+
+	return obj, nil
+}
+
+func (api *testS3API) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	key := aws.ToString(params.Key)
+
+	if _, ok := api.objects[key]; ok && aws.ToString(params.IfNoneMatch) == "*" {
+		return nil, testS3Error(http.StatusPreconditionFailed)
+	}
+
+	if params.IfMatch != nil {
+		if _, err := api.get(key, params.IfMatch); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := io.ReadAll(params.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sum := md5.Sum(b)
+	obj := &testS3Object{body: b, etag: `"` + hex.EncodeToString(sum[:]) + `"`, lastModified: time.Now()}
+	api.objects[key] = obj
+
+	return &s3.PutObjectOutput{ETag: aws.String(obj.etag)}, nil
+}
+
+func (api *testS3API) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	obj, err := api.get(aws.ToString(params.Key), params.IfMatch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	output := &s3.GetObjectOutput{
+		Body:         io.NopCloser(bytes.NewReader(obj.body)),
+		ETag:         aws.String(obj.etag),
+		LastModified: aws.Time(obj.lastModified),
+	}
+
+	return output, nil
+}
+
+func (api *testS3API) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	key := aws.ToString(params.Key)
+
+	if _, err := api.get(key, params.IfMatch); err != nil {
+		return nil, err
+	}
+
+	delete(api.objects, key)
+
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestS3API(t *testing.T) {
+	api := &testS3API{objects: map[string]*testS3Object{}}
+	obj := s3lock.New(api, "s3lock-test", "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Minute), s3lock.WithFencingToken())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), lock.Token())
+
+	// Other clients cannot lock it
+	_, err = obj.Lock(t.Context())
+	var heldErr *s3lock.LockHeldError
+	require.ErrorAs(t, err, &heldErr)
+
+	// Refresh
+	err = lock.Refresh(t.Context(), time.Minute)
+	require.NoError(t, err)
+
+	// Unlock
+	err = lock.Unlock()
+	require.NoError(t, err)
+	require.NotContains(t, api.objects, "lock-obj")
+
+	// Already unlocked
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+
+	// Lock from JSON
+	lock, err = obj.Lock(t.Context(), s3lock.WithFencingToken())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), lock.Token())
+	j, err := lock.MarshalJSON()
+	require.NoError(t, err)
+	lock, err = s3lock.NewLockFromJSON(api, j)
+	require.NoError(t, err)
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
+
+func TestS3APIWithoutList(t *testing.T) {
+	api := &testS3API{objects: map[string]*testS3Object{}}
+	obj := s3lock.New(api, "s3lock-test", "lock-obj")
+
+	// The fair queue needs ListObjectsV2
+	_, err := obj.LockWait(t.Context(), s3lock.WithFairQueue(0))
+	require.ErrorContains(t, err, "S3 client does not support ListObjectsV2")
+}
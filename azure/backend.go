@@ -0,0 +1,157 @@
+// Package azure provides the s3lock backend for Azure Blob Storage.
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/winebarrel/s3lock"
+)
+
+// Backend stores lock objects as blobs in a container using ETag conditions.
+type Backend struct {
+	az        *azblob.Client
+	container string
+}
+
+var _ s3lock.Backend = (*Backend)(nil)
+
+func NewBackend(azClient *azblob.Client, container string) *Backend {
+	return &Backend{
+		az:        azClient,
+		container: container,
+	}
+}
+
+func (b *Backend) Scheme() string {
+	return "az"
+}
+
+func (b *Backend) Bucket() string {
+	return b.container
+}
+
+func (b *Backend) Create(ctx context.Context, key string, data []byte) (string, error) {
+	return b.upload(ctx, key, data, &blob.ModifiedAccessConditions{IfNoneMatch: to.Ptr(azcore.ETagAny)})
+}
+
+func (b *Backend) Get(ctx context.Context, key string, ifMatch string) (*s3lock.Entry, error) {
+	options := &azblob.DownloadStreamOptions{}
+
+	if ifMatch != "" {
+		options.AccessConditions = accessConditions(&blob.ModifiedAccessConditions{IfMatch: to.Ptr(azcore.ETag(ifMatch))})
+	}
+
+	resp, err := b.az.DownloadStream(ctx, b.container, key, options)
+
+	if err != nil {
+		return nil, azError(err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &s3lock.Entry{
+		Data: data,
+	}
+
+	if resp.ETag != nil {
+		entry.ETag = string(*resp.ETag)
+	}
+
+	if resp.LastModified != nil {
+		entry.LastModified = *resp.LastModified
+	}
+
+	return entry, nil
+}
+
+func (b *Backend) Replace(ctx context.Context, key string, data []byte, ifMatch string) (string, error) {
+	return b.upload(ctx, key, data, &blob.ModifiedAccessConditions{IfMatch: to.Ptr(azcore.ETag(ifMatch))})
+}
+
+func (b *Backend) Delete(ctx context.Context, key string, ifMatch string) error {
+	options := &azblob.DeleteBlobOptions{
+		AccessConditions: accessConditions(&blob.ModifiedAccessConditions{IfMatch: to.Ptr(azcore.ETag(ifMatch))}),
+	}
+
+	_, err := b.az.DeleteBlob(ctx, b.container, key, options)
+
+	return azError(err)
+}
+
+func (b *Backend) List(ctx context.Context, prefix string) ([]string, error) {
+	pager := b.az.NewListBlobsFlatPager(b.container, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	keys := []string{}
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			if item.Name != nil {
+				keys = append(keys, *item.Name)
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+func (b *Backend) upload(ctx context.Context, key string, data []byte, cond *blob.ModifiedAccessConditions) (string, error) {
+	options := &azblob.UploadBufferOptions{
+		AccessConditions: accessConditions(cond),
+	}
+
+	resp, err := b.az.UploadBuffer(ctx, b.container, key, data, options)
+
+	if err != nil {
+		return "", azError(err)
+	}
+
+	if resp.ETag == nil {
+		return "", nil
+	}
+
+	return string(*resp.ETag), nil
+}
+
+func accessConditions(cond *blob.ModifiedAccessConditions) *blob.AccessConditions {
+	return &blob.AccessConditions{ModifiedAccessConditions: cond}
+}
+
+func azError(err error) error {
+	// Azure returns 409 BlobAlreadyExists instead of 412 for "If-None-Match: *"
+	if bloberror.HasCode(err, bloberror.BlobAlreadyExists) {
+		return fmt.Errorf("%w: %w", s3lock.ErrPreconditionFailed, err)
+	}
+
+	var respErr *azcore.ResponseError
+
+	if errors.As(err, &respErr) {
+		switch respErr.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", s3lock.ErrObjectNotFound, err)
+		case http.StatusPreconditionFailed:
+			return fmt.Errorf("%w: %w", s3lock.ErrPreconditionFailed, err)
+		}
+	}
+
+	return err
+}
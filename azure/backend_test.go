@@ -0,0 +1,139 @@
+package azure_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/azure"
+)
+
+// https://learn.microsoft.com/en-us/azure/storage/common/storage-use-azurite#connection-strings
+const testConnectionString = "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;" +
+	"AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;" +
+	"BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1;"
+
+func testNewAzureClient(t *testing.T) *azblob.Client {
+	t.Helper()
+	client, err := azblob.NewClientFromConnectionString(testConnectionString, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.CreateContainer(t.Context(), "s3lock-test", nil)
+
+	if err != nil && !bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func testDeleteBlob(t *testing.T, client *azblob.Client, key string) {
+	t.Helper()
+	client.DeleteBlob(t.Context(), "s3lock-test", key, nil)
+}
+
+func TestBackend(t *testing.T) {
+	azCli := testNewAzureClient(t)
+	testDeleteBlob(t, azCli, "lock-obj")
+	testDeleteBlob(t, azCli, "locks/lock-obj2")
+	backend := azure.NewBackend(azCli, "s3lock-test")
+
+	// Create
+	etag, err := backend.Create(t.Context(), "lock-obj", []byte("foo"))
+	require.NoError(t, err)
+	require.NotEmpty(t, etag)
+	_, err = backend.Create(t.Context(), "lock-obj", []byte("bar"))
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+
+	// Get
+	entry, err := backend.Get(t.Context(), "lock-obj", "")
+	require.NoError(t, err)
+	require.Equal(t, "foo", string(entry.Data))
+	require.Equal(t, etag, entry.ETag)
+	require.WithinDuration(t, time.Now(), entry.LastModified, 10*time.Second)
+	_, err = backend.Get(t.Context(), "lock-obj", `"invalid"`)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	_, err = backend.Get(t.Context(), "not-found", "")
+	require.ErrorIs(t, err, s3lock.ErrObjectNotFound)
+
+	// Replace
+	_, err = backend.Replace(t.Context(), "lock-obj", []byte("bar"), `"invalid"`)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	etag2, err := backend.Replace(t.Context(), "lock-obj", []byte("bar"), etag)
+	require.NoError(t, err)
+	require.NotEqual(t, etag, etag2)
+
+	// List
+	_, err = backend.Create(t.Context(), "locks/lock-obj2", []byte("baz"))
+	require.NoError(t, err)
+	keys, err := backend.List(t.Context(), "locks/")
+	require.NoError(t, err)
+	require.Equal(t, []string{"locks/lock-obj2"}, keys)
+
+	// Delete
+	err = backend.Delete(t.Context(), "lock-obj", etag)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	err = backend.Delete(t.Context(), "lock-obj", etag2)
+	require.NoError(t, err)
+	err = backend.Delete(t.Context(), "lock-obj", etag2)
+	require.ErrorIs(t, err, s3lock.ErrObjectNotFound)
+}
+
+func TestLock(t *testing.T) {
+	azCli := testNewAzureClient(t)
+	testDeleteBlob(t, azCli, "lock-obj")
+	testDeleteBlob(t, azCli, "lock-obj.token")
+	obj := s3lock.NewWithBackend(azure.NewBackend(azCli, "s3lock-test"), "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithFencingToken())
+	require.NoError(t, err)
+	require.Equal(t, "az://s3lock-test/lock-obj", lock.String())
+	require.Equal(t, uint64(1), lock.Token())
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Lock file
+	j, err := json.Marshal(lock)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Scheme":"az","Bucket":"s3lock-test","Key":"lock-obj","Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","ETag":".+","Token":1}`, string(j))
+
+	lock2, err := s3lock.NewLockFromJSONWithResolver(j, func(_ string, container string) (s3lock.Backend, error) {
+		return azure.NewBackend(azCli, container), nil
+	})
+
+	require.NoError(t, err)
+
+	// Unlock
+	err = lock2.Unlock()
+	require.NoError(t, err)
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+	_, err = obj.Info(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrNotLocked)
+}
+
+func TestLockTakeOver(t *testing.T) {
+	azCli := testNewAzureClient(t)
+	testDeleteBlob(t, azCli, "lock-obj")
+	obj := s3lock.NewWithBackend(azure.NewBackend(azCli, "s3lock-test"), "lock-obj")
+
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(500*time.Millisecond))
+	require.NoError(t, err)
+
+	time.Sleep(1 * time.Second)
+	lock2, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+	err = lock2.Unlock()
+	require.NoError(t, err)
+}
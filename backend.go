@@ -0,0 +1,44 @@
+package s3lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrObjectNotFound     = errors.New("object not found")
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Backend is the compare-and-swap storage of lock objects.
+// Conditional operations must be atomic: they return ErrPreconditionFailed
+// if the object exists (Create) or its ETag does not match (Get, Replace, Delete),
+// and ErrObjectNotFound if the object does not exist.
+type Backend interface {
+	// Scheme is the URL scheme of the backend, e.g. "s3".
+	Scheme() string
+	// Bucket is the namespace of the keys, e.g. the S3 bucket or the local directory.
+	Bucket() string
+	// Create stores the object only if it does not exist and returns its ETag.
+	Create(ctx context.Context, key string, data []byte) (string, error)
+	// Get returns the object. If ifMatch is not empty, the ETag must match it.
+	Get(ctx context.Context, key string, ifMatch string) (*Entry, error)
+	// Replace overwrites the object only if its ETag matches and returns the new ETag.
+	Replace(ctx context.Context, key string, data []byte, ifMatch string) (string, error)
+	// Delete removes the object only if its ETag matches.
+	Delete(ctx context.Context, key string, ifMatch string) error
+	// List returns the keys under the prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+type Entry struct {
+	Data         []byte
+	ETag         string
+	LastModified time.Time
+}
+
+func backendURL(b Backend, key string) string {
+	return fmt.Sprintf("%s://%s/%s", b.Scheme(), b.Bucket(), key)
+}
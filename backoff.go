@@ -0,0 +1,75 @@
+package s3lock
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff decides how long LockWait waits before the next attempt.
+// attempt starts at 1, and prev is the previous wait (0 for the first attempt).
+// Implementations must be safe for concurrent use.
+type Backoff interface {
+	Next(attempt int, prev time.Duration) time.Duration
+}
+
+type fixedBackoff time.Duration
+
+// FixedBackoff waits the same interval before every attempt.
+func FixedBackoff(interval time.Duration) Backoff {
+	return fixedBackoff(interval)
+}
+
+func (b fixedBackoff) Next(_ int, _ time.Duration) time.Duration {
+	return time.Duration(b)
+}
+
+type exponentialBackoff struct {
+	base time.Duration
+	max  time.Duration
+}
+
+// ExponentialBackoff doubles the wait from base for every attempt, up to maxInterval.
+// A non-positive base is replaced with LockWaitInterval, and maxInterval is raised to base,
+// so that LockWait never polls without waiting.
+func ExponentialBackoff(base time.Duration, maxInterval time.Duration) Backoff {
+	base, maxInterval = backoffRange(base, maxInterval)
+	return &exponentialBackoff{base: base, max: maxInterval}
+}
+
+func (b *exponentialBackoff) Next(attempt int, _ time.Duration) time.Duration {
+	wait := b.base
+
+	for i := 1; i < attempt && wait < b.max; i++ {
+		wait *= 2
+	}
+
+	return min(wait, b.max)
+}
+
+func backoffRange(base time.Duration, maxInterval time.Duration) (time.Duration, time.Duration) {
+	if base <= 0 {
+		base = LockWaitInterval
+	}
+
+	return base, max(maxInterval, base)
+}
+
+type decorrelatedJitterBackoff struct {
+	base time.Duration
+	max  time.Duration
+}
+
+// DecorrelatedJitterBackoff waits a random duration between base and three times the previous wait, up to maxInterval,
+// so that waiters spread out instead of retrying in lockstep.
+// base and maxInterval are adjusted like ExponentialBackoff.
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func DecorrelatedJitterBackoff(base time.Duration, maxInterval time.Duration) Backoff {
+	base, maxInterval = backoffRange(base, maxInterval)
+	return &decorrelatedJitterBackoff{base: base, max: maxInterval}
+}
+
+func (b *decorrelatedJitterBackoff) Next(_ int, prev time.Duration) time.Duration {
+	upper := max(prev*3, b.base)
+	wait := b.base + rand.N(upper-b.base+1)
+	return min(wait, b.max)
+}
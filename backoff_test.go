@@ -0,0 +1,87 @@
+package s3lock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+)
+
+func TestFixedBackoff(t *testing.T) {
+	b := s3lock.FixedBackoff(time.Second)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		require.Equal(t, time.Second, b.Next(attempt, time.Second))
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := s3lock.ExponentialBackoff(100*time.Millisecond, time.Second)
+
+	tt := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: 100 * time.Millisecond},
+		{attempt: 2, expected: 200 * time.Millisecond},
+		{attempt: 3, expected: 400 * time.Millisecond},
+		{attempt: 4, expected: 800 * time.Millisecond},
+		{attempt: 5, expected: time.Second},
+		{attempt: 1000, expected: time.Second},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.expected, b.Next(tc.attempt, 0))
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	b := s3lock.DecorrelatedJitterBackoff(100*time.Millisecond, time.Second)
+	var wait time.Duration
+	waits := map[time.Duration]struct{}{}
+
+	for attempt := 1; attempt <= 100; attempt++ {
+		prev := wait
+		wait = b.Next(attempt, prev)
+		require.GreaterOrEqual(t, wait, 100*time.Millisecond)
+		require.LessOrEqual(t, wait, max(prev*3, 100*time.Millisecond))
+		require.LessOrEqual(t, wait, time.Second)
+		waits[wait] = struct{}{}
+	}
+
+	// Randomized
+	require.Greater(t, len(waits), 1)
+}
+
+func TestLockWaitWithBackoff(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj", s3lock.WithBackoff(s3lock.DecorrelatedJitterBackoff(10*time.Millisecond, 100*time.Millisecond)))
+	lock, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		lock.Unlock()
+	}()
+
+	lock2, err := obj.LockWait(t.Context())
+	require.NoError(t, err)
+	err = lock2.Unlock()
+	require.NoError(t, err)
+}
+
+func TestBackoffInvalidRange(t *testing.T) {
+	// LockWaitInterval is 100ms in tests
+	for _, b := range []s3lock.Backoff{
+		s3lock.ExponentialBackoff(100*time.Millisecond, 0),
+		s3lock.DecorrelatedJitterBackoff(100*time.Millisecond, 0),
+		s3lock.ExponentialBackoff(0, 0),
+		s3lock.DecorrelatedJitterBackoff(-time.Second, 0),
+	} {
+		require.Equal(t, 100*time.Millisecond, b.Next(1, 0))
+		require.Equal(t, 100*time.Millisecond, b.Next(2, 100*time.Millisecond))
+	}
+}
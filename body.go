@@ -0,0 +1,51 @@
+package s3lock
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// lockBody is the content of the lock object.
+type lockBody struct {
+	Id         string
+	AcquiredAt time.Time
+	ExpiresAt  time.Time `json:",omitzero"`
+	Holder     *Holder   `json:",omitempty"`
+	Owner      string    `json:",omitempty"`
+	HoldCount  int       `json:",omitzero"`
+}
+
+func newLockBody(id string, ttl time.Duration) *lockBody {
+	now := time.Now().UTC()
+
+	b := &lockBody{
+		Id:         id,
+		AcquiredAt: now,
+	}
+
+	if ttl > 0 {
+		b.ExpiresAt = now.Add(ttl)
+	}
+
+	return b
+}
+
+func parseLockBody(data []byte) *lockBody {
+	b := &lockBody{}
+
+	if err := json.Unmarshal(data, b); err == nil && b.Id != "" {
+		return b
+	}
+
+	// older versions write only the lock id
+	return &lockBody{Id: string(data)}
+}
+
+func (b *lockBody) expired(now time.Time) bool {
+	return !b.ExpiresAt.IsZero() && !now.Before(b.ExpiresAt)
+}
+
+// holdCount returns the number of the holds by the owner, which is 1 for locks without owner.
+func (b *lockBody) holdCount() int {
+	return max(b.HoldCount, 1)
+}
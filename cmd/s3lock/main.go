@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"os"
 
 	"github.com/alecthomas/kong"
@@ -13,9 +15,13 @@ import (
 var version string
 
 var cli struct {
-	Version kong.VersionFlag
-	Lock    subcmd.LockCmd   `cmd:""`
-	Unlock  subcmd.UnlockCmd `cmd:""`
+	Version     kong.VersionFlag
+	Lock        subcmd.LockCmd        `cmd:""`
+	Unlock      subcmd.UnlockCmd      `cmd:""`
+	Exec        subcmd.ExecCmd        `cmd:""`
+	Status      subcmd.StatusCmd      `cmd:"" aliases:"info"`
+	List        subcmd.ListCmd        `cmd:""`
+	ForceUnlock subcmd.ForceUnlockCmd `cmd:""`
 }
 
 func main() {
@@ -25,6 +31,15 @@ func main() {
 	err = kctx.Run(&subcmd.Context{
 		Output: os.Stdout,
 		S3:     s3.NewFromConfig(cfg),
+		Logger: slog.New(slog.NewJSONHandler(os.Stderr, nil)),
+		Stderr: os.Stderr,
 	})
+
+	var exitCodeErr *subcmd.ExitCodeError
+
+	if errors.As(err, &exitCodeErr) {
+		os.Exit(exitCodeErr.Code)
+	}
+
 	kctx.FatalIfErrorf(err)
 }
@@ -0,0 +1,165 @@
+package subcmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/azure"
+	"github.com/winebarrel/s3lock/gcs"
+)
+
+// Backend returns the backend of the URL scheme, e.g., "s3", "gs", "az" or "file".
+func (cmdCtx *Context) Backend(scheme string, bucket string) (s3lock.Backend, error) {
+	switch scheme {
+	case "s3":
+		return s3lock.NewS3Backend(cmdCtx.S3, bucket), nil
+	case "gs":
+		// The GCS client is created only when needed, since it requires credentials
+		if cmdCtx.GCS == nil {
+			client, err := storage.NewClient(context.Background())
+
+			if err != nil {
+				return nil, err
+			}
+
+			cmdCtx.GCS = client
+		}
+
+		return gcs.NewBackend(cmdCtx.GCS, bucket), nil
+	case "az":
+		if cmdCtx.Azure == nil {
+			client, err := newAzureClient()
+
+			if err != nil {
+				return nil, err
+			}
+
+			cmdCtx.Azure = client
+		}
+
+		return azure.NewBackend(cmdCtx.Azure, bucket), nil
+	case "file":
+		return s3lock.NewFileBackend(bucket), nil
+	}
+
+	return nil, fmt.Errorf("unsupported URL scheme: %s", scheme)
+}
+
+// newAzureClient uses the same environment variables as Azure CLI.
+func newAzureClient() (*azblob.Client, error) {
+	if connStr := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); connStr != "" {
+		return azblob.NewClientFromConnectionString(connStr, nil)
+	}
+
+	account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+
+	if account == "" {
+		return nil, errors.New("AZURE_STORAGE_CONNECTION_STRING or AZURE_STORAGE_ACCOUNT is required")
+	}
+
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return azblob.NewClient(fmt.Sprintf("https://%s.blob.core.windows.net/", account), cred, nil)
+}
+
+func (cmdCtx *Context) object(u *url.URL, opts ...s3lock.Option) (*s3lock.Object, error) {
+	bucket, key := splitLockURL(u)
+	backend, err := cmdCtx.Backend(u.Scheme, bucket)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s3lock.NewWithBackend(backend, key, opts...), nil
+}
+
+type lockFunc func(ctx context.Context, opts ...s3lock.Option) (*s3lock.Lock, error)
+
+// lockFuncs returns Lock/LockWait of the object, or Acquire/AcquireWait of the semaphore if slots > 0.
+func (cmdCtx *Context) lockFuncs(u *url.URL, slots uint) (lockFunc, lockFunc, error) {
+	if slots == 0 {
+		obj, err := cmdCtx.object(u)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return obj.Lock, obj.LockWait, nil
+	}
+
+	bucket, prefix := splitLockURL(u)
+	backend, err := cmdCtx.Backend(u.Scheme, bucket)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sem := s3lock.NewSemaphoreWithBackend(backend, prefix, int(slots))
+
+	return sem.Acquire, sem.AcquireWait, nil
+}
+
+func (cmdCtx *Context) lockFromJSON(data []byte) (*s3lock.Lock, error) {
+	return s3lock.NewLockFromJSONWithResolver(data, cmdCtx.Backend)
+}
+
+// locksFromJSON reads a lock file of a single lock, or a JSON array of the locks written for multiple URLs.
+func (cmdCtx *Context) locksFromJSON(data []byte) ([]*s3lock.Lock, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		ml, err := s3lock.NewMultiLockFromJSONWithResolver(data, cmdCtx.Backend)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return ml.Locks(), nil
+	}
+
+	lock, err := cmdCtx.lockFromJSON(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []*s3lock.Lock{lock}, nil
+}
+
+// splitLockURL returns the bucket and the key of the lock object.
+// The bucket of a file URL is the directory of the lock object, e.g., file:///var/lock/job -> "/var/lock", "job".
+func splitLockURL(u *url.URL) (string, string) {
+	if u.Scheme == "file" {
+		p := u.Host + u.Path
+		return path.Dir(p), path.Base(p)
+	}
+
+	return u.Host, strings.TrimPrefix(u.Path, "/")
+}
+
+func validateLockURL(u *url.URL) error {
+	switch u.Scheme {
+	case "s3", "gs", "az":
+		if u.Host != "" && strings.TrimPrefix(u.Path, "/") != "" {
+			return nil
+		}
+	case "file":
+		if p := u.Host + u.Path; p != "" && !strings.HasSuffix(p, "/") {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid lock URL: %s", u)
+}
@@ -2,11 +2,37 @@ package subcmd
 
 import (
 	"io"
+	"log/slog"
+	"os"
 
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
 type Context struct {
 	Output io.Writer
 	S3     *s3.Client
+	GCS    *storage.Client
+	Azure  *azblob.Client
+	// Logger receives the audit records, e.g., of force-unlock.
+	Logger *slog.Logger
+	// Stderr receives the diagnostics that must not be mixed with the output of the command of exec (default: os.Stderr).
+	Stderr io.Writer
+}
+
+func (cmdCtx *Context) stderr() io.Writer {
+	if cmdCtx.Stderr == nil {
+		return os.Stderr
+	}
+
+	return cmdCtx.Stderr
+}
+
+func (cmdCtx *Context) logger() *slog.Logger {
+	if cmdCtx.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+
+	return cmdCtx.Logger
 }
@@ -0,0 +1,141 @@
+package subcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/winebarrel/s3lock"
+)
+
+type ExecCmd struct {
+	URL          *url.URL `arg:"" help:"URL of the object to lock, e.g., s3://bucket/lock-obj-key, gs://bucket/lock-obj-key, az://container/lock-obj-key or file:///path/to/lock-obj-key"`
+	Command      []string `arg:"" passthrough:"" help:"Command to run while holding the lock."`
+	Wait         uint     `short:"w" help:"Fail if the lock cannot be acquired within seconds."`
+	WaitOptions  `embed:""`
+	TTL          time.Duration `help:"Lock lease duration, renewed while the command is running (default: no expiry)"`
+	FencingToken bool          `help:"Issue a monotonic fencing token to S3LOCK_FENCING_TOKEN."`
+	Description  string        `short:"d" help:"Description of the lock holder."`
+	Slots        uint          `help:"Allow up to N holders by locking one of the slot objects <url>/0 to <url>/<N-1>."`
+	Owner        string        `help:"Owner identity. The owner can lock the object again while holding it, and it is deleted by the last unlock."`
+}
+
+type ExitCodeError struct {
+	Code int
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (cmd *ExecCmd) AfterApply() error {
+	if err := validateLockURL(cmd.URL); err != nil {
+		return err
+	}
+
+	return cmd.WaitOptions.validate()
+}
+
+func (cmd *ExecCmd) Run(cmdCtx *Context) error {
+	lockCmd := &LockCmd{
+		URLs:         []*url.URL{cmd.URL},
+		Wait:         cmd.Wait,
+		WaitOptions:  cmd.WaitOptions,
+		TTL:          cmd.TTL,
+		FencingToken: cmd.FencingToken,
+		Description:  cmd.Description,
+		Slots:        cmd.Slots,
+		Owner:        cmd.Owner,
+	}
+
+	lock, err := lockCmd.lock(cmdCtx)
+
+	if err != nil {
+		return err
+	}
+
+	child := exec.Command(cmd.Command[0], cmd.Command[1:]...) //nolint:gosec
+	child.Stdin = os.Stdin
+	child.Stdout = cmdCtx.Output
+	child.Stderr = os.Stderr
+	child.Env = os.Environ()
+
+	if cmd.FencingToken {
+		child.Env = append(child.Env, "S3LOCK_FENCING_TOKEN="+strconv.FormatUint(lock.Token(), 10))
+	}
+
+	runErr := cmd.run(cmdCtx, child, lock)
+	err = unlock(cmdCtx, lock)
+
+	if err != nil {
+		if runErr == nil {
+			return err
+		}
+
+		// pass the exit code of the command through even if the lock has been lost
+		fmt.Fprintf(cmdCtx.stderr(), "failed to unlock %s: %s\n", lock, err) //nolint:errcheck
+	}
+
+	var exitErr *exec.ExitError
+
+	if errors.As(runErr, &exitErr) {
+		return &ExitCodeError{Code: exitCode(exitErr)}
+	}
+
+	return runErr
+}
+
+func (cmd *ExecCmd) run(cmdCtx *Context, child *exec.Cmd, lock *s3lock.Lock) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if cmd.TTL > 0 {
+		err := lock.KeepAlive(ctx, cmd.TTL/3)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err := child.Start()
+
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- child.Wait() }()
+	lost := lock.Lost()
+
+	for {
+		select {
+		case sig := <-sigs:
+			child.Process.Signal(sig) //nolint:errcheck
+		case <-lost:
+			lost = nil
+			fmt.Fprintf(cmdCtx.stderr(), "%s has been lost\n", lock) //nolint:errcheck
+			child.Process.Signal(syscall.SIGTERM)                    //nolint:errcheck
+		case err := <-done:
+			return err
+		}
+	}
+}
+
+func exitCode(exitErr *exec.ExitError) int {
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return exitErr.ExitCode()
+}
@@ -0,0 +1,223 @@
+package subcmd_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/cmd/subcmd"
+)
+
+func testRegisterLockResponders(t *testing.T) *int {
+	t.Helper()
+	var lockId string
+	deleted := 0
+
+	httpmock.RegisterResponder(http.MethodPut, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=PutObject", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, `*`, req.Header.Get("If-None-Match"))
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		lockId = regexp.MustCompile(`\w{8}-\w{4}-\w{4}-\w{4}-\w{12}`).FindString(string(body))
+		resp := httpmock.NewStringResponse(http.StatusOK, "")
+		resp.Header.Set("ETag", `"my-etag"`)
+		return resp, nil
+	})
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, `"my-etag"`, req.Header.Get("If-Match"))
+		return httpmock.NewStringResponse(http.StatusOK, `{"Id":"`+lockId+`"}`), nil
+	})
+
+	httpmock.RegisterResponder(http.MethodDelete, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=DeleteObject", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, `"my-etag"`, req.Header.Get("If-Match"))
+		deleted++
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	return &deleted
+}
+
+func TestExecCmd(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	cmd := &subcmd.ExecCmd{
+		URL:     &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		Command: []string{"sh", "-c", "echo hello"},
+	}
+
+	deleted := testRegisterLockResponders(t)
+	var buf bytes.Buffer
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: &buf,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "s3://s3lock-test/lock-obj has been locked\nhello\ns3://s3lock-test/lock-obj has been unlocked\n", buf.String())
+	require.Equal(t, 1, *deleted)
+}
+
+func TestExecCmdExitCode(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	cmd := &subcmd.ExecCmd{
+		URL:     &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		Command: []string{"sh", "-c", "exit 3"},
+	}
+
+	deleted := testRegisterLockResponders(t)
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: io.Discard,
+	})
+
+	// The lock is released even if the command fails
+	require.Equal(t, &subcmd.ExitCodeError{Code: 3}, err)
+	require.Equal(t, 1, *deleted)
+}
+
+func TestExecCmdSignaled(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	cmd := &subcmd.ExecCmd{
+		URL:     &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		Command: []string{"sh", "-c", "kill -TERM $$"},
+	}
+
+	deleted := testRegisterLockResponders(t)
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: io.Discard,
+	})
+
+	require.Equal(t, &subcmd.ExitCodeError{Code: 143}, err)
+	require.Equal(t, 1, *deleted)
+}
+
+func TestExecCmdLockAlreadyHeld(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	cmd := &subcmd.ExecCmd{
+		URL:     &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		Command: []string{"sh", "-c", "echo hello"},
+	}
+
+	httpmock.RegisterResponder(http.MethodPut, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=PutObject", func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewStringResponse(http.StatusPreconditionFailed, ""), nil
+	})
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewStringResponse(http.StatusOK, `{"Id":"other-id","AcquiredAt":"2025-01-01T00:00:00Z"}`), nil
+	})
+
+	var buf bytes.Buffer
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: &buf,
+	})
+
+	// The command is not run
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+	require.Empty(t, buf.String())
+}
+
+func TestExecCmdKeepAlive(t *testing.T) {
+	dir := t.TempDir()
+	obj := s3lock.NewWithBackend(s3lock.NewFileBackend(dir), "lock-obj")
+
+	cmd := &subcmd.ExecCmd{
+		URL:     &url.URL{Scheme: "file", Path: dir + "/lock-obj"},
+		Command: []string{"sh", "-c", "sleep 1"},
+		TTL:     300 * time.Millisecond,
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- cmd.Run(&subcmd.Context{Output: io.Discard, Stderr: io.Discard})
+	}()
+
+	// The lease is renewed while the command is running
+	time.Sleep(700 * time.Millisecond)
+	_, err := obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	err = <-done
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "lock-obj"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestExecCmdLost(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "lock-obj")
+
+	cmd := &subcmd.ExecCmd{
+		URL:     &url.URL{Scheme: "file", Path: lockPath},
+		Command: []string{"sh", "-c", "echo hello; exec sleep 10"},
+		TTL:     300 * time.Millisecond,
+	}
+
+	var buf, stderr bytes.Buffer
+	done := make(chan error, 1)
+
+	go func() {
+		done <- cmd.Run(&subcmd.Context{Output: &buf, Stderr: &stderr})
+	}()
+
+	// Taken by another process
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(lockPath)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	err := os.WriteFile(lockPath, []byte(`{"Id":"other-id"}`), 0644)
+	require.NoError(t, err)
+
+	// The command is terminated and its exit code is passed through
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "command not terminated")
+	}
+
+	require.Equal(t, &subcmd.ExitCodeError{Code: 143}, err)
+	lockURL := "file://" + lockPath
+	require.Equal(t, lockURL+" has been locked\nhello\n", buf.String())
+	require.Equal(t, lockURL+" has been lost\nfailed to unlock "+lockURL+": lock mismatch\n", stderr.String())
+}
@@ -0,0 +1,80 @@
+package subcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/winebarrel/s3lock"
+)
+
+type ForceUnlockCmd struct {
+	URL        *url.URL `arg:"" help:"URL of the lock object, e.g., s3://bucket/lock-obj-key, gs://bucket/lock-obj-key, az://container/lock-obj-key or file:///path/to/lock-obj-key"`
+	Yes        bool     `short:"y" help:"Unlock without checking the lock id or ETag."`
+	ExpectId   string   `help:"Unlock only if the lock id matches."`
+	ExpectETag string   `name:"expect-etag" help:"Unlock only if the ETag matches."`
+}
+
+func (cmd *ForceUnlockCmd) AfterApply() error {
+	if err := validateLockURL(cmd.URL); err != nil {
+		return err
+	}
+
+	if !cmd.Yes && cmd.ExpectId == "" && cmd.ExpectETag == "" {
+		return errors.New("--yes, --expect-id or --expect-etag is required")
+	}
+
+	return nil
+}
+
+func (cmd *ForceUnlockCmd) Run(cmdCtx *Context) error {
+	ctx := context.Background()
+	obj, err := cmdCtx.object(cmd.URL)
+
+	if err != nil {
+		return err
+	}
+
+	info, err := obj.Info(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// Show the lock being removed
+	printStatus(cmdCtx, &lockStatus{
+		URL:      cmd.URL.String(),
+		Locked:   true,
+		LockInfo: info,
+		Expired:  info.Expired(),
+	})
+
+	if cmd.ExpectId != "" && cmd.ExpectId != info.Id {
+		return fmt.Errorf("lock id mismatch: expected %s, got %s", cmd.ExpectId, info.Id)
+	}
+
+	if cmd.ExpectETag != "" && strings.Trim(cmd.ExpectETag, `"`) != strings.Trim(info.ETag, `"`) {
+		return fmt.Errorf("ETag mismatch: expected %s, got %s", cmd.ExpectETag, info.ETag)
+	}
+
+	err = obj.ForceUnlock(ctx, info.ETag)
+
+	if err != nil {
+		return err
+	}
+
+	// Record who removed whose lock
+	cmdCtx.logger().Info("force-unlocked",
+		"url", cmd.URL.String(),
+		"operator", s3lock.CurrentHolder(),
+		"id", info.Id,
+		"etag", info.ETag,
+		"holder", info.Holder,
+	)
+
+	fmt.Fprintf(cmdCtx.Output, "%s has been force-unlocked\n", cmd.URL) //nolint:errcheck
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package subcmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/cmd/subcmd"
+)
+
+func testRegisterForceUnlockResponders(t *testing.T, deleteStatus int) *int {
+	t.Helper()
+	deleted := 0
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"Id":"my-id","AcquiredAt":"2025-01-01T10:02:00Z","Holder":{"Hostname":"host-a","PID":1234,"User":"scott"}}`)
+		resp.Header.Set("ETag", `"my-etag"`)
+		resp.Header.Set("Last-Modified", "Wed, 01 Jan 2025 10:02:00 GMT")
+		return resp, nil
+	})
+
+	httpmock.RegisterResponder(http.MethodDelete, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=DeleteObject", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, `"my-etag"`, req.Header.Get("If-Match"))
+		deleted++
+		return httpmock.NewStringResponse(deleteStatus, ""), nil
+	})
+
+	return &deleted
+}
+
+func TestForceUnlockCmd(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	cmd := &subcmd.ForceUnlockCmd{
+		URL: &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		Yes: true,
+	}
+
+	deleted := testRegisterForceUnlockResponders(t, http.StatusNoContent)
+	var buf, audit bytes.Buffer
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: &buf,
+		Logger: slog.New(slog.NewJSONHandler(&audit, nil)),
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, `url: s3://s3lock-test/lock-obj
+locked: true
+id: my-id
+etag: "my-etag"
+last modified: 2025-01-01T10:02:00Z
+acquired at: 2025-01-01T10:02:00Z
+holder: host-a (pid 1234, user scott)
+s3://s3lock-test/lock-obj has been force-unlocked
+`, buf.String())
+	require.Equal(t, 1, *deleted)
+
+	// Audit record
+	var record map[string]any
+	err = json.Unmarshal(audit.Bytes(), &record)
+	require.NoError(t, err)
+	require.Equal(t, "force-unlocked", record["msg"])
+	require.Equal(t, "s3://s3lock-test/lock-obj", record["url"])
+	require.Equal(t, "my-id", record["id"])
+	require.Equal(t, `"my-etag"`, record["etag"])
+	require.Equal(t, map[string]any{"Hostname": "host-a", "PID": float64(1234), "User": "scott"}, record["holder"])
+	operator := record["operator"].(map[string]any)
+	require.Equal(t, float64(os.Getpid()), operator["PID"])
+}
+
+func TestForceUnlockCmdExpect(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	deleted := testRegisterForceUnlockResponders(t, http.StatusNoContent)
+
+	tt := []struct {
+		cmd      *subcmd.ForceUnlockCmd
+		expected string
+	}{
+		{
+			cmd:      &subcmd.ForceUnlockCmd{ExpectId: "other-id"},
+			expected: "lock id mismatch: expected other-id, got my-id",
+		},
+		{
+			cmd:      &subcmd.ForceUnlockCmd{ExpectETag: "other-etag"},
+			expected: `ETag mismatch: expected other-etag, got "my-etag"`,
+		},
+		{
+			cmd:      &subcmd.ForceUnlockCmd{ExpectId: "my-id", ExpectETag: "other-etag"},
+			expected: `ETag mismatch: expected other-etag, got "my-etag"`,
+		},
+		{
+			cmd: &subcmd.ForceUnlockCmd{ExpectId: "my-id", ExpectETag: "my-etag"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc.cmd.URL = &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}
+
+		err := tc.cmd.Run(&subcmd.Context{
+			S3:     s3cli,
+			Output: io.Discard,
+		})
+
+		if tc.expected == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, tc.expected)
+		}
+	}
+
+	require.Equal(t, 1, *deleted)
+}
+
+func TestForceUnlockCmdReacquired(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	cmd := &subcmd.ForceUnlockCmd{
+		URL: &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		Yes: true,
+	}
+
+	// The lock was re-acquired after it was read
+	testRegisterForceUnlockResponders(t, http.StatusPreconditionFailed)
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: io.Discard,
+	})
+
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+}
+
+func TestForceUnlockCmdRequireConfirmation(t *testing.T) {
+	cmd := &subcmd.ForceUnlockCmd{
+		URL: &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+	}
+
+	err := cmd.AfterApply()
+	require.EqualError(t, err, "--yes, --expect-id or --expect-etag is required")
+}
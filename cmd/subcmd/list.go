@@ -0,0 +1,106 @@
+package subcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/winebarrel/s3lock"
+)
+
+type ListCmd struct {
+	URL       *url.URL      `arg:"" help:"URL prefix of the lock objects, e.g., s3://bucket/prefix/, gs://bucket/prefix/, az://container/prefix/ or file:///path/to/dir"`
+	Expired   bool          `help:"Show only expired locks."`
+	OlderThan time.Duration `help:"Show only locks held longer than the duration, e.g., 1h."`
+	JSON      bool          `help:"Output in JSON."`
+}
+
+type listedLock struct {
+	*s3lock.LockInfo
+	Expired bool
+}
+
+func (cmd *ListCmd) AfterApply() error {
+	switch cmd.URL.Scheme {
+	case "s3", "gs", "az":
+		if cmd.URL.Host != "" {
+			return nil
+		}
+	case "file":
+		if cmd.URL.Host+cmd.URL.Path != "" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid lock URL: %s", cmd.URL)
+}
+
+func (cmd *ListCmd) Run(cmdCtx *Context) error {
+	// A file URL is the directory of the lock objects
+	bucket, prefix := cmd.URL.Host, strings.TrimPrefix(cmd.URL.Path, "/")
+
+	if cmd.URL.Scheme == "file" {
+		bucket, prefix = cmd.URL.Host+cmd.URL.Path, ""
+	}
+
+	backend, err := cmdCtx.Backend(cmd.URL.Scheme, bucket)
+
+	if err != nil {
+		return err
+	}
+
+	infos, err := s3lock.ListWithBackend(context.Background(), backend, prefix)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	locks := []*listedLock{}
+
+	for _, info := range infos {
+		l := &listedLock{LockInfo: info, Expired: info.Expired()}
+
+		if cmd.Expired && !l.Expired {
+			continue
+		}
+
+		if cmd.OlderThan > 0 && now.Sub(info.HeldSince()) <= cmd.OlderThan {
+			continue
+		}
+
+		locks = append(locks, l)
+	}
+
+	if cmd.JSON {
+		return json.NewEncoder(cmdCtx.Output).Encode(locks)
+	}
+
+	w := tabwriter.NewWriter(cmdCtx.Output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tAGE\tEXPIRY\tHOLDER") //nolint:errcheck
+
+	for _, l := range locks {
+		age := now.Sub(l.HeldSince()).Truncate(time.Second)
+		expiry := "-"
+
+		if l.Expired {
+			expiry = "expired"
+		} else if !l.ExpiresAt.IsZero() {
+			expiry = "in " + l.ExpiresAt.Sub(now).Truncate(time.Second).String()
+		}
+
+		holder := "-"
+
+		if h := l.Holder; h != nil {
+			holder = fmt.Sprintf("%s (pid %d)", h.Hostname, h.PID)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", l.Key, age, expiry, holder) //nolint:errcheck
+	}
+
+	return w.Flush()
+}
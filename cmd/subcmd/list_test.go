@@ -0,0 +1,131 @@
+package subcmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock/cmd/subcmd"
+)
+
+func testRegisterListResponders(t *testing.T) {
+	t.Helper()
+	now := time.Now().UTC()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "locks/", req.URL.Query().Get("prefix"))
+		return httpmock.NewStringResponse(http.StatusOK, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+  <Name>s3lock-test</Name>
+  <Prefix>locks/</Prefix>
+  <KeyCount>4</KeyCount>
+  <IsTruncated>false</IsTruncated>
+  <Contents><Key>locks/lock-a</Key></Contents>
+  <Contents><Key>locks/lock-a.token</Key></Contents>
+  <Contents><Key>locks/lock-b</Key></Contents>
+  <Contents><Key>locks/lock-c</Key></Contents>
+</ListBucketResult>`), nil
+	})
+
+	bodies := map[string]string{
+		"lock-a": `{"Id":"id-a","AcquiredAt":"` + now.Add(-2*time.Hour).Format(time.RFC3339) + `","ExpiresAt":"` + now.Add(-1*time.Hour).Format(time.RFC3339) + `","Holder":{"Hostname":"host-a","PID":1234}}`,
+		"lock-b": `{"Id":"id-b","AcquiredAt":"` + now.Add(-10*time.Minute).Format(time.RFC3339) + `","ExpiresAt":"` + now.Add(time.Hour).Format(time.RFC3339) + `","Holder":{"Hostname":"host-b","PID":5678}}`,
+		"lock-c": `{"Id":"id-c","AcquiredAt":"` + now.Add(-3*time.Hour).Format(time.RFC3339) + `"}`,
+	}
+
+	for name, body := range bodies {
+		httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/locks/"+name+"?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusOK, body)
+			resp.Header.Set("ETag", `"etag-`+name+`"`)
+			resp.Header.Set("Last-Modified", now.Format(http.TimeFormat))
+			return resp, nil
+		})
+	}
+}
+
+func TestListCmd(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	cmd := &subcmd.ListCmd{
+		URL: &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/locks/"},
+	}
+
+	testRegisterListResponders(t)
+	var buf bytes.Buffer
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: &buf,
+	})
+
+	require.NoError(t, err)
+	require.Regexp(t, `^KEY +AGE +EXPIRY +HOLDER
+locks/lock-a +2h0m\ds +expired +host-a \(pid 1234\)
+locks/lock-b +10m\ds +in 5\dm\d+s +host-b \(pid 5678\)
+locks/lock-c +3h0m\ds +- +-
+$`, buf.String())
+}
+
+func TestListCmdWithFilter(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	testRegisterListResponders(t)
+
+	tt := []struct {
+		cmd      *subcmd.ListCmd
+		expected []string
+	}{
+		{
+			cmd:      &subcmd.ListCmd{Expired: true},
+			expected: []string{"locks/lock-a"},
+		},
+		{
+			cmd:      &subcmd.ListCmd{OlderThan: time.Hour},
+			expected: []string{"locks/lock-a", "locks/lock-c"},
+		},
+		{
+			cmd:      &subcmd.ListCmd{Expired: true, OlderThan: 150 * time.Minute},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tt {
+		tc.cmd.URL = &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/locks/"}
+		tc.cmd.JSON = true
+		var buf bytes.Buffer
+
+		err := tc.cmd.Run(&subcmd.Context{
+			S3:     s3cli,
+			Output: &buf,
+		})
+
+		require.NoError(t, err)
+		var locks []struct{ Key string }
+		err = json.Unmarshal(buf.Bytes(), &locks)
+		require.NoError(t, err)
+		keys := []string{}
+
+		for _, l := range locks {
+			keys = append(keys, l.Key)
+		}
+
+		require.Equal(t, tc.expected, keys)
+	}
+}
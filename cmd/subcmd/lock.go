@@ -2,62 +2,119 @@ package subcmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
-	"path/filepath"
-	"strings"
+	"path"
 	"time"
 
 	"github.com/winebarrel/s3lock"
 )
 
 type LockCmd struct {
-	S3URL  *url.URL `arg:"" name:"s3-url" help:"S3 URL of the object to lock, e.g., s3://bucket/lock-obj-key"`
-	Wait   uint     `short:"w" help:"Fail if the lock cannot be acquired within seconds."`
-	Output string   `short:"o" help:"Lock file output path (default: <lock-obj-key>.lock)"`
+	URLs         []*url.URL `arg:"" name:"url" help:"URLs of the objects to lock, e.g., s3://bucket/lock-obj-key, gs://bucket/lock-obj-key, az://container/lock-obj-key or file:///path/to/lock-obj-key. Multiple objects are locked together in sorted order."`
+	Wait         uint       `short:"w" help:"Fail if the lock cannot be acquired within seconds."`
+	WaitOptions  `embed:""`
+	TTL          time.Duration `help:"Lock lease duration, e.g., 30m (default: no expiry)"`
+	FencingToken bool          `help:"Issue a monotonic fencing token to the lock file."`
+	Description  string        `short:"d" help:"Description of the lock holder."`
+	Output       string        `short:"o" help:"Lock file output path (default: <lock-obj-key>.lock)"`
+	Slots        uint          `help:"Allow up to N holders by locking one of the slot objects <url>/0 to <url>/<N-1>."`
+	Owner        string        `help:"Owner identity. The owner can lock the object again while holding it, and it is deleted by the last unlock. The lock file must not exist."`
+}
+
+type WaitOptions struct {
+	Backoff         string        `enum:"fixed,exponential,jitter" default:"fixed" help:"Retry strategy while waiting: fixed, exponential or jitter (decorrelated jitter)."`
+	WaitInterval    time.Duration `default:"1s" help:"Interval between attempts while waiting, or the initial interval of exponential and jitter."`
+	MaxWaitInterval time.Duration `default:"30s" help:"Maximum interval between attempts of exponential and jitter."`
+	Fair            bool          `help:"Wait in a first-come, first-served queue with other --fair waiters."`
+}
+
+func (o *WaitOptions) backoff() s3lock.Backoff {
+	switch o.Backoff {
+	case "exponential":
+		return s3lock.ExponentialBackoff(o.WaitInterval, o.MaxWaitInterval)
+	case "jitter":
+		return s3lock.DecorrelatedJitterBackoff(o.WaitInterval, o.MaxWaitInterval)
+	}
+
+	return s3lock.FixedBackoff(o.WaitInterval)
+}
+
+func (o *WaitOptions) validate() error {
+	if (o.Backoff == "exponential" || o.Backoff == "jitter") && (o.MaxWaitInterval <= 0 || o.MaxWaitInterval < o.WaitInterval) {
+		return fmt.Errorf("--max-wait-interval must be positive and not less than --wait-interval: %s", o.MaxWaitInterval)
+	}
+
+	return nil
 }
 
 func (cmd *LockCmd) AfterApply() error {
-	if cmd.S3URL.Scheme != "s3" || cmd.S3URL.Host == "" || strings.TrimPrefix(cmd.S3URL.Path, "/") == "" {
-		return fmt.Errorf("invalid S3 URL: %s", cmd.S3URL)
+	for _, u := range cmd.URLs {
+		if err := validateLockURL(u); err != nil {
+			return err
+		}
+	}
+
+	if err := cmd.WaitOptions.validate(); err != nil {
+		return err
+	}
+
+	if len(cmd.URLs) > 1 && cmd.Slots > 0 {
+		return errors.New("--slots cannot be used with multiple URLs")
 	}
 
 	if cmd.Output == "" {
-		cmd.Output = filepath.Base(cmd.S3URL.Path) + ".lock"
+		_, key := splitLockURL(cmd.URLs[0])
+		cmd.Output = path.Base(key) + ".lock"
 	}
 
 	return nil
 }
 
 func (cmd *LockCmd) Run(cmdCtx *Context) error {
-	ctx := context.Background()
-	lockObj := s3lock.New(cmdCtx.S3, cmd.S3URL.Host, strings.TrimPrefix(cmd.S3URL.Path, "/"))
-
-	var lock *s3lock.Lock
+	var lock json.Marshaler
+	var file *os.File
 	var err error
 
-	if cmd.Wait > 0 {
-		ctx, cancel := context.WithTimeout(ctx, time.Duration(cmd.Wait)*time.Second)
-		lock, err = lockObj.LockWait(ctx)
-		cancel()
+	if cmd.Owner != "" {
+		// Do not overwrite the lock file of another hold of the owner
+		file, err = os.OpenFile(cmd.Output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close() //nolint:errcheck
+	}
+
+	if len(cmd.URLs) > 1 {
+		lock, err = cmd.lockAll(cmdCtx)
 	} else {
-		lock, err = lockObj.Lock(ctx)
+		lock, err = cmd.lock(cmdCtx)
 	}
 
 	if err != nil {
+		if file != nil {
+			os.Remove(cmd.Output) //nolint:errcheck
+		}
+
 		return err
 	}
 
-	fmt.Fprintf(cmdCtx.Output, "%s has been locked\n", cmd.S3URL) //nolint:errcheck
-
 	j, err := lock.MarshalJSON()
 
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(cmd.Output, j, 0644)
+	if file != nil {
+		_, err = file.Write(j)
+	} else {
+		err = os.WriteFile(cmd.Output, j, 0644)
+	}
 
 	if err != nil {
 		return err
@@ -67,3 +124,93 @@ func (cmd *LockCmd) Run(cmdCtx *Context) error {
 
 	return nil
 }
+
+func (cmd *LockCmd) options() []s3lock.Option {
+	holder := s3lock.CurrentHolder()
+	holder.Description = cmd.Description
+	opts := []s3lock.Option{s3lock.WithTTL(cmd.TTL), s3lock.WithHolder(holder)}
+
+	if cmd.FencingToken {
+		opts = append(opts, s3lock.WithFencingToken())
+	}
+
+	if cmd.Owner != "" {
+		opts = append(opts, s3lock.WithOwner(cmd.Owner))
+	}
+
+	if cmd.Wait > 0 {
+		if cmd.WaitInterval > 0 {
+			opts = append(opts, s3lock.WithBackoff(cmd.backoff()))
+		}
+
+		if cmd.Fair {
+			opts = append(opts, s3lock.WithFairQueue(0))
+		}
+	}
+
+	return opts
+}
+
+func (cmd *LockCmd) lock(cmdCtx *Context) (*s3lock.Lock, error) {
+	ctx := context.Background()
+	lockFn, lockWaitFn, err := cmdCtx.lockFuncs(cmd.URLs[0], cmd.Slots)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var lock *s3lock.Lock
+
+	if cmd.Wait > 0 {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(cmd.Wait)*time.Second)
+		lock, err = lockWaitFn(ctx, cmd.options()...)
+		cancel()
+	} else {
+		lock, err = lockFn(ctx, cmd.options()...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(cmdCtx.Output, "%s has been locked\n", lock) //nolint:errcheck
+
+	return lock, nil
+}
+
+func (cmd *LockCmd) lockAll(cmdCtx *Context) (*s3lock.MultiLock, error) {
+	ctx := context.Background()
+	objs := []*s3lock.Object{}
+	opts := cmd.options()
+
+	for _, u := range cmd.URLs {
+		obj, err := cmdCtx.object(u, opts...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		objs = append(objs, obj)
+	}
+
+	var ml *s3lock.MultiLock
+	var err error
+
+	if cmd.Wait > 0 {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(cmd.Wait)*time.Second)
+		ml, err = s3lock.LockAllWait(ctx, objs...)
+		cancel()
+	} else {
+		ml, err = s3lock.LockAll(ctx, objs...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lock := range ml.Locks() {
+		fmt.Fprintf(cmdCtx.Output, "%s has been locked\n", lock) //nolint:errcheck
+	}
+
+	return ml, nil
+}
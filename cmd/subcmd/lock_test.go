@@ -2,16 +2,23 @@ package subcmd_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/fsouza/fake-gcs-server/fakestorage"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/require"
 	"github.com/winebarrel/s3lock"
@@ -28,7 +35,7 @@ func TestLockCmd(t *testing.T) {
 
 	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
 	cmd := &subcmd.LockCmd{
-		S3URL:  &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		URLs:   []*url.URL{{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}},
 		Output: lockFile,
 	}
 
@@ -65,7 +72,7 @@ func TestLockCmdWithWait(t *testing.T) {
 
 	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
 	cmd := &subcmd.LockCmd{
-		S3URL:  &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		URLs:   []*url.URL{{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}},
 		Wait:   3,
 		Output: lockFile,
 	}
@@ -86,6 +93,10 @@ func TestLockCmdWithWait(t *testing.T) {
 		return httpmock.NewStringResponse(http.StatusOK, ""), nil
 	})
 
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewStringResponse(http.StatusOK, `{"Id":"other-id","AcquiredAt":"2025-01-01T00:00:00Z"}`), nil
+	})
+
 	var buf bytes.Buffer
 
 	err := cmd.Run(&subcmd.Context{
@@ -101,6 +112,130 @@ func TestLockCmdWithWait(t *testing.T) {
 	require.Regexp(t, `{"Bucket":"s3lock-test","Key":"lock-obj","Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","ETag":".*"}`, string(b))
 }
 
+func TestLockCmdWithTTL(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
+	cmd := &subcmd.LockCmd{
+		URLs:   []*url.URL{{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}},
+		TTL:    10 * time.Minute,
+		Output: lockFile,
+	}
+
+	httpmock.RegisterResponder(http.MethodPut, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=PutObject", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, `*`, req.Header.Get("If-None-Match"))
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.Regexp(t, `{"Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","AcquiredAt":".*","ExpiresAt":".*"}`, string(body))
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: io.Discard,
+	})
+
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(lockFile)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Bucket":"s3lock-test","Key":"lock-obj","Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","ETag":".*","TTL":600000000000,"ExpiresAt":".*"}`, string(b))
+}
+
+func TestLockCmdWithDescription(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
+	cmd := &subcmd.LockCmd{
+		URLs:        []*url.URL{{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}},
+		Description: "nightly job",
+		Output:      lockFile,
+	}
+
+	httpmock.RegisterResponder(http.MethodPut, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=PutObject", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, `*`, req.Header.Get("If-None-Match"))
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.Regexp(t, `"Holder":{"Hostname":".+","PID":\d+,"User":".+","Description":"nightly job"}`, string(body))
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: io.Discard,
+	})
+
+	require.NoError(t, err)
+}
+
+func TestLockCmdWithFencingToken(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
+	cmd := &subcmd.LockCmd{
+		URLs:         []*url.URL{{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}},
+		FencingToken: true,
+		Output:       lockFile,
+	}
+
+	var lockId string
+
+	httpmock.RegisterResponder(http.MethodPut, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=PutObject", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, `*`, req.Header.Get("If-None-Match"))
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		lockId = regexp.MustCompile(`\w{8}-\w{4}-\w{4}-\w{4}-\w{12}`).FindString(string(body))
+		resp := httpmock.NewStringResponse(http.StatusOK, "")
+		resp.Header.Set("ETag", `"lock-etag"`)
+		return resp, nil
+	})
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, `"lock-etag"`, req.Header.Get("If-Match"))
+		return httpmock.NewStringResponse(http.StatusOK, `{"Id":"`+lockId+`"}`), nil
+	})
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj.token?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, "41")
+		resp.Header.Set("ETag", `"token-etag"`)
+		return resp, nil
+	})
+
+	httpmock.RegisterResponder(http.MethodPut, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj.token?x-id=PutObject", func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, `"token-etag"`, req.Header.Get("If-Match"))
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.Regexp(t, `\b42\b`, string(body))
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: io.Discard,
+	})
+
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(lockFile)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Bucket":"s3lock-test","Key":"lock-obj","Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","ETag":"\\"lock-etag\\"","Token":42}`, string(b))
+}
+
 func TestLockCmdLockAlreadyHeld(t *testing.T) {
 	hc := &http.Client{}
 	httpmock.ActivateNonDefault(hc)
@@ -111,7 +246,7 @@ func TestLockCmdLockAlreadyHeld(t *testing.T) {
 
 	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
 	cmd := &subcmd.LockCmd{
-		S3URL:  &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		URLs:   []*url.URL{{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}},
 		Output: lockFile,
 	}
 
@@ -123,16 +258,72 @@ func TestLockCmdLockAlreadyHeld(t *testing.T) {
 		return httpmock.NewStringResponse(http.StatusPreconditionFailed, ""), nil
 	})
 
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewStringResponse(http.StatusOK, `{"Id":"other-id","AcquiredAt":"2025-01-01T10:02:00Z","Holder":{"Hostname":"host-a","PID":1234}}`), nil
+	})
+
 	err := cmd.Run(&subcmd.Context{
 		S3:     s3cli,
 		Output: io.Discard,
 	})
 
 	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+	require.EqualError(t, err, "lock already held by host-a (pid 1234) since 2025-01-01 10:02 UTC")
 	_, err = os.Stat(lockFile)
 	require.True(t, os.IsNotExist(err))
 }
 
+func TestLockCmdTakeOver(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
+	cmd := &subcmd.LockCmd{
+		URLs:   []*url.URL{{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}},
+		Output: lockFile,
+	}
+
+	httpmock.RegisterResponder(http.MethodPut, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=PutObject", func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.Regexp(t, `\w{8}-\w{4}-\w{4}-\w{4}-\w{12}`, string(body))
+
+		// Overwrite the expired lock
+		if req.Header.Get("If-Match") == `"expired-etag"` {
+			resp := httpmock.NewStringResponse(http.StatusOK, "")
+			resp.Header.Set("ETag", `"new-etag"`)
+			return resp, nil
+		}
+
+		require.Equal(t, `*`, req.Header.Get("If-None-Match"))
+		return httpmock.NewStringResponse(http.StatusPreconditionFailed, ""), nil
+	})
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"Id":"other-id","AcquiredAt":"2025-01-01T00:00:00Z","ExpiresAt":"2025-01-01T00:10:00Z"}`)
+		resp.Header.Set("ETag", `"expired-etag"`)
+		return resp, nil
+	})
+
+	var buf bytes.Buffer
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: &buf,
+	})
+
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "s3://s3lock-test/lock-obj has been locked")
+
+	b, err := os.ReadFile(lockFile)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Bucket":"s3lock-test","Key":"lock-obj","Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","ETag":"\\"new-etag\\""}`, string(b))
+}
+
 func TestLockCmdFatal(t *testing.T) {
 	hc := &http.Client{}
 	httpmock.ActivateNonDefault(hc)
@@ -144,7 +335,7 @@ func TestLockCmdFatal(t *testing.T) {
 
 	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
 	cmd := &subcmd.LockCmd{
-		S3URL:  &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"},
+		URLs:   []*url.URL{{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}},
 		Output: lockFile,
 	}
 
@@ -165,3 +356,313 @@ func TestLockCmdFatal(t *testing.T) {
 	_, err = os.Stat(lockFile)
 	require.True(t, os.IsNotExist(err))
 }
+
+func TestLockCmdWithFileURL(t *testing.T) {
+	dir := t.TempDir()
+	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
+	cmd := &subcmd.LockCmd{
+		URLs:   []*url.URL{{Scheme: "file", Path: dir + "/lock-obj"}},
+		Output: lockFile,
+	}
+
+	var buf bytes.Buffer
+	cmdCtx := &subcmd.Context{Output: &buf}
+	err := cmd.Run(cmdCtx)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "file://"+dir+"/lock-obj has been locked")
+
+	b, err := os.ReadFile(lockFile)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Scheme":"file","Bucket":".*","Key":"lock-obj","Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","ETag":".*"}`, string(b))
+
+	// Locked by another client
+	err = cmd.Run(cmdCtx)
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Unlock
+	unlockCmd := &subcmd.UnlockCmd{LockFile: lockFile}
+	err = unlockCmd.Run(cmdCtx)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "file://"+dir+"/lock-obj has been unlocked")
+	_, err = os.Stat(filepath.Join(dir, "lock-obj"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestLockCmdWithGCSURL(t *testing.T) {
+	server, err := fakestorage.NewServerWithOptions(fakestorage.Options{NoListener: true})
+	require.NoError(t, err)
+	t.Cleanup(server.Stop)
+	server.CreateBucketWithOpts(fakestorage.CreateBucketOpts{Name: "s3lock-test"})
+
+	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
+	cmd := &subcmd.LockCmd{
+		URLs:   []*url.URL{{Scheme: "gs", Host: "s3lock-test", Path: "/lock-obj"}},
+		Output: lockFile,
+	}
+
+	var buf bytes.Buffer
+	cmdCtx := &subcmd.Context{GCS: server.Client(), Output: &buf}
+	err = cmd.Run(cmdCtx)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "gs://s3lock-test/lock-obj has been locked")
+
+	b, err := os.ReadFile(lockFile)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Scheme":"gs","Bucket":"s3lock-test","Key":"lock-obj","Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","ETag":"\d+"}`, string(b))
+
+	// Locked by another client
+	err = cmd.Run(cmdCtx)
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Unlock
+	unlockCmd := &subcmd.UnlockCmd{LockFile: lockFile}
+	err = unlockCmd.Run(cmdCtx)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "gs://s3lock-test/lock-obj has been unlocked")
+}
+
+func TestLockCmdWithAzureURL(t *testing.T) {
+	// Azurite
+	azCli, err := azblob.NewClientFromConnectionString("DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;"+
+		"AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;"+
+		"BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1;", nil)
+
+	require.NoError(t, err)
+	azCli.CreateContainer(t.Context(), "s3lock-test", nil)
+	azCli.DeleteBlob(t.Context(), "s3lock-test", "lock-obj", nil)
+
+	lockFile := filepath.Join(t.TempDir(), "lock-obj.lock")
+	cmd := &subcmd.LockCmd{
+		URLs:   []*url.URL{{Scheme: "az", Host: "s3lock-test", Path: "/lock-obj"}},
+		Output: lockFile,
+	}
+
+	var buf bytes.Buffer
+	cmdCtx := &subcmd.Context{Azure: azCli, Output: &buf}
+	err = cmd.Run(cmdCtx)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "az://s3lock-test/lock-obj has been locked")
+
+	b, err := os.ReadFile(lockFile)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Scheme":"az","Bucket":"s3lock-test","Key":"lock-obj","Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","ETag":".+"}`, string(b))
+
+	// Locked by another client
+	err = cmd.Run(cmdCtx)
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Unlock
+	unlockCmd := &subcmd.UnlockCmd{LockFile: lockFile}
+	err = unlockCmd.Run(cmdCtx)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "az://s3lock-test/lock-obj has been unlocked")
+}
+
+func TestLockCmdWithBackoff(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := s3lock.NewWithBackend(s3lock.NewFileBackend(dir), "lock-obj").Lock(t.Context())
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		lock.Unlock()
+	}()
+
+	cmd := &subcmd.LockCmd{
+		URLs: []*url.URL{{Scheme: "file", Path: dir + "/lock-obj"}},
+		Wait: 5,
+		WaitOptions: subcmd.WaitOptions{
+			Backoff:         "jitter",
+			WaitInterval:    10 * time.Millisecond,
+			MaxWaitInterval: 100 * time.Millisecond,
+		},
+		Output: filepath.Join(t.TempDir(), "lock-obj.lock"),
+	}
+
+	var buf bytes.Buffer
+	err = cmd.Run(&subcmd.Context{Output: &buf})
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "file://"+dir+"/lock-obj has been locked")
+}
+
+func TestLockCmdWithFairQueue(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := s3lock.NewWithBackend(s3lock.NewFileBackend(dir), "lock-obj").Lock(t.Context())
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		lock.Unlock()
+	}()
+
+	cmd := &subcmd.LockCmd{
+		URLs: []*url.URL{{Scheme: "file", Path: dir + "/lock-obj"}},
+		Wait: 5,
+		WaitOptions: subcmd.WaitOptions{
+			Backoff:      "fixed",
+			WaitInterval: 10 * time.Millisecond,
+			Fair:         true,
+		},
+		Output: filepath.Join(t.TempDir(), "lock-obj.lock"),
+	}
+
+	var buf bytes.Buffer
+	err = cmd.Run(&subcmd.Context{Output: &buf})
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "file://"+dir+"/lock-obj has been locked")
+
+	// The ticket is removed
+	keys, err := s3lock.NewFileBackend(dir).List(t.Context(), "lock-obj.queue/ticket-")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func TestLockCmdWithSlots(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+
+	for i := range 2 {
+		cmd := &subcmd.LockCmd{
+			URLs:   []*url.URL{{Scheme: "file", Path: dir + "/sem"}},
+			Slots:  2,
+			Output: filepath.Join(t.TempDir(), "sem.lock"),
+		}
+
+		err := cmd.Run(&subcmd.Context{Output: &buf})
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), fmt.Sprintf("file://%s/sem/%d has been locked", dir, i))
+	}
+
+	// All slots are held
+	cmd := &subcmd.LockCmd{
+		URLs:   []*url.URL{{Scheme: "file", Path: dir + "/sem"}},
+		Slots:  2,
+		Output: filepath.Join(t.TempDir(), "sem.lock"),
+	}
+
+	err := cmd.Run(&subcmd.Context{Output: &buf})
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+}
+
+func TestLockCmdWithMultipleURLs(t *testing.T) {
+	dir := t.TempDir()
+	lockFile := filepath.Join(t.TempDir(), "lock-b.lock")
+
+	cmd := &subcmd.LockCmd{
+		URLs: []*url.URL{
+			{Scheme: "file", Path: dir + "/lock-b"},
+			{Scheme: "file", Path: dir + "/lock-a"},
+		},
+		Output: lockFile,
+	}
+
+	var buf bytes.Buffer
+	err := cmd.Run(&subcmd.Context{Output: &buf})
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("file://%[1]s/lock-a has been locked\nfile://%[1]s/lock-b has been locked\ncreate %[2]s\n", dir, lockFile), buf.String())
+
+	// A single lock file of the locks
+	b, err := os.ReadFile(lockFile)
+	require.NoError(t, err)
+	var locks []map[string]any
+	err = json.Unmarshal(b, &locks)
+	require.NoError(t, err)
+	require.Len(t, locks, 2)
+
+	// Status of each lock
+	buf.Reset()
+	statusCmd := &subcmd.StatusCmd{Target: lockFile}
+	err = statusCmd.Run(&subcmd.Context{Output: &buf})
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), fmt.Sprintf("url: file://%s/lock-a\nlocked: true\n", dir))
+	require.Contains(t, buf.String(), fmt.Sprintf("url: file://%s/lock-b\nlocked: true\n", dir))
+	require.Equal(t, 2, strings.Count(buf.String(), "(matched)"))
+
+	// Unlocked in the reverse order
+	buf.Reset()
+	unlockCmd := &subcmd.UnlockCmd{LockFile: lockFile}
+	err = unlockCmd.Run(&subcmd.Context{Output: &buf})
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("file://%[1]s/lock-b has been unlocked\nfile://%[1]s/lock-a has been unlocked\ndelete %[2]s\n", dir, lockFile), buf.String())
+}
+
+func TestLockCmdWithOwner(t *testing.T) {
+	dir := t.TempDir()
+	outerFile := filepath.Join(t.TempDir(), "outer.lock")
+	innerFile := filepath.Join(t.TempDir(), "inner.lock")
+	var buf bytes.Buffer
+
+	for _, output := range []string{outerFile, innerFile} {
+		cmd := &subcmd.LockCmd{
+			URLs:   []*url.URL{{Scheme: "file", Path: dir + "/lock-obj"}},
+			Owner:  "job-1",
+			Output: output,
+		}
+
+		err := cmd.Run(&subcmd.Context{Output: &buf})
+		require.NoError(t, err)
+	}
+
+	// The lock file of another hold is not overwritten
+	outerJSON, err := os.ReadFile(outerFile)
+	require.NoError(t, err)
+	cmd := &subcmd.LockCmd{
+		URLs:   []*url.URL{{Scheme: "file", Path: dir + "/lock-obj"}},
+		Owner:  "job-1",
+		Output: outerFile,
+	}
+	err = cmd.Run(&subcmd.Context{Output: &buf})
+	require.ErrorIs(t, err, os.ErrExist)
+	j, err := os.ReadFile(outerFile)
+	require.NoError(t, err)
+	require.Equal(t, outerJSON, j)
+
+	// The lock file is removed if the lock fails
+	otherFile := filepath.Join(t.TempDir(), "other.lock")
+	cmd = &subcmd.LockCmd{
+		URLs:   []*url.URL{{Scheme: "file", Path: dir + "/lock-obj"}},
+		Owner:  "job-2",
+		Output: otherFile,
+	}
+	err = cmd.Run(&subcmd.Context{Output: &buf})
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+	_, err = os.Stat(otherFile)
+	require.True(t, os.IsNotExist(err))
+
+	buf.Reset()
+	statusCmd := &subcmd.StatusCmd{Target: "file://" + dir + "/lock-obj"}
+	err = statusCmd.Run(&subcmd.Context{Output: &buf})
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "owner: job-1 (hold count 2)\n")
+
+	// Deleted by the last unlock
+	unlockCmd := &subcmd.UnlockCmd{LockFile: innerFile}
+	err = unlockCmd.Run(&subcmd.Context{Output: &buf})
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "lock-obj"))
+	require.NoError(t, err)
+
+	unlockCmd = &subcmd.UnlockCmd{LockFile: outerFile}
+	err = unlockCmd.Run(&subcmd.Context{Output: &buf})
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "lock-obj"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestLockCmdInvalidMaxWaitInterval(t *testing.T) {
+	u := &url.URL{Scheme: "s3", Host: "s3lock-test", Path: "/lock-obj"}
+	waitOpts := subcmd.WaitOptions{Backoff: "exponential", WaitInterval: time.Second, MaxWaitInterval: 0}
+
+	lockCmd := &subcmd.LockCmd{URLs: []*url.URL{u}, Wait: 60, WaitOptions: waitOpts}
+	err := lockCmd.AfterApply()
+	require.ErrorContains(t, err, "--max-wait-interval must be positive and not less than --wait-interval: 0s")
+
+	execCmd := &subcmd.ExecCmd{URL: u, Wait: 60, WaitOptions: waitOpts}
+	err = execCmd.AfterApply()
+	require.ErrorContains(t, err, "--max-wait-interval must be positive and not less than --wait-interval: 0s")
+
+	// The maximum is not used by fixed
+	lockCmd.Backoff = "fixed"
+	err = lockCmd.AfterApply()
+	require.NoError(t, err)
+}
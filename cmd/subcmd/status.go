@@ -0,0 +1,185 @@
+package subcmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/winebarrel/s3lock"
+)
+
+type StatusCmd struct {
+	Target string `arg:"" help:"URL of the lock object or lock file path."`
+	JSON   bool   `help:"Output in JSON."`
+}
+
+type lockStatus struct {
+	URL    string
+	Locked bool
+	*s3lock.LockInfo
+	Expired  bool   `json:",omitempty"`
+	LockFile string `json:",omitempty"`
+	Matched  *bool  `json:",omitempty"`
+	Reason   string `json:",omitempty"`
+}
+
+func (cmd *StatusCmd) Run(cmdCtx *Context) error {
+	ctx := context.Background()
+	statuses := []*lockStatus{}
+
+	if strings.Contains(cmd.Target, "://") {
+		u, err := url.Parse(cmd.Target)
+
+		if err != nil {
+			return err
+		}
+
+		if err := validateLockURL(u); err != nil {
+			return err
+		}
+
+		obj, err := cmdCtx.object(u)
+
+		if err != nil {
+			return err
+		}
+
+		status := &lockStatus{URL: u.String()}
+		err = setLockInfo(ctx, obj, status)
+
+		if err != nil {
+			return err
+		}
+
+		statuses = append(statuses, status)
+	} else {
+		j, err := os.ReadFile(cmd.Target)
+
+		if err != nil {
+			return err
+		}
+
+		// a lock file of multiple URLs has multiple locks
+		locks, err := cmdCtx.locksFromJSON(j)
+
+		if err != nil {
+			return err
+		}
+
+		for _, lock := range locks {
+			status := &lockStatus{URL: lock.String(), LockFile: cmd.Target}
+			matched := true
+			err = lock.Validate(ctx)
+
+			if err != nil {
+				if !errors.Is(err, s3lock.ErrLockMismatch) && !errors.Is(err, s3lock.ErrAlreadyUnlocked) {
+					return err
+				}
+
+				matched = false
+				status.Reason = err.Error()
+			}
+
+			status.Matched = &matched
+			err = setLockInfo(ctx, s3lock.NewWithBackend(lock.Backend(), lock.Key()), status)
+
+			if err != nil {
+				return err
+			}
+
+			statuses = append(statuses, status)
+		}
+	}
+
+	for i, status := range statuses {
+		if cmd.JSON {
+			err := json.NewEncoder(cmdCtx.Output).Encode(status)
+
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintln(cmdCtx.Output) //nolint:errcheck
+		}
+
+		printStatus(cmdCtx, status)
+	}
+
+	return nil
+}
+
+func setLockInfo(ctx context.Context, obj *s3lock.Object, status *lockStatus) error {
+	info, err := obj.Info(ctx)
+
+	if err != nil && !errors.Is(err, s3lock.ErrNotLocked) {
+		return err
+	}
+
+	if info != nil {
+		status.Locked = true
+		status.LockInfo = info
+		status.Expired = info.Expired()
+	}
+
+	return nil
+}
+
+func printStatus(cmdCtx *Context, status *lockStatus) {
+	lines := []string{
+		"url: " + status.URL,
+		fmt.Sprintf("locked: %t", status.Locked),
+	}
+
+	if info := status.LockInfo; info != nil {
+		lines = append(lines,
+			"id: "+info.Id,
+			"etag: "+info.ETag,
+			"last modified: "+info.LastModified.Format(time.RFC3339),
+		)
+
+		if !info.AcquiredAt.IsZero() {
+			lines = append(lines, "acquired at: "+info.AcquiredAt.Format(time.RFC3339))
+		}
+
+		if !info.ExpiresAt.IsZero() {
+			expiresAt := "expires at: " + info.ExpiresAt.Format(time.RFC3339)
+
+			if status.Expired {
+				expiresAt += " (expired)"
+			}
+
+			lines = append(lines, expiresAt)
+		}
+
+		if h := info.Holder; h != nil {
+			lines = append(lines, fmt.Sprintf("holder: %s (pid %d, user %s)", h.Hostname, h.PID, h.User))
+
+			if h.Description != "" {
+				lines = append(lines, "description: "+h.Description)
+			}
+		}
+
+		if info.Owner != "" {
+			lines = append(lines, fmt.Sprintf("owner: %s (hold count %d)", info.Owner, max(info.HoldCount, 1)))
+		}
+	}
+
+	if status.LockFile != "" {
+		if *status.Matched {
+			lines = append(lines, fmt.Sprintf("lock file: %s (matched)", status.LockFile))
+		} else {
+			lines = append(lines, fmt.Sprintf("lock file: %s (%s)", status.LockFile, status.Reason))
+		}
+	}
+
+	fmt.Fprintln(cmdCtx.Output, strings.Join(lines, "\n")) //nolint:errcheck
+}
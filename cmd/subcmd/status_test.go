@@ -0,0 +1,172 @@
+package subcmd_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock/cmd/subcmd"
+)
+
+func TestStatusCmd(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	cmd := &subcmd.StatusCmd{
+		Target: "s3://s3lock-test/lock-obj",
+	}
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"Id":"my-id","AcquiredAt":"2025-01-01T10:02:00Z","ExpiresAt":"2025-01-01T10:12:00Z","Holder":{"Hostname":"host-a","PID":1234,"User":"scott","Description":"nightly job"}}`)
+		resp.Header.Set("ETag", `"my-etag"`)
+		resp.Header.Set("Last-Modified", "Wed, 01 Jan 2025 10:02:00 GMT")
+		return resp, nil
+	})
+
+	var buf bytes.Buffer
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: &buf,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, `url: s3://s3lock-test/lock-obj
+locked: true
+id: my-id
+etag: "my-etag"
+last modified: 2025-01-01T10:02:00Z
+acquired at: 2025-01-01T10:02:00Z
+expires at: 2025-01-01T10:12:00Z (expired)
+holder: host-a (pid 1234, user scott)
+description: nightly job
+`, buf.String())
+}
+
+func TestStatusCmdNotLocked(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	cmd := &subcmd.StatusCmd{
+		Target: "s3://s3lock-test/lock-obj",
+		JSON:   true,
+	}
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewStringResponse(http.StatusNotFound, ""), nil
+	})
+
+	var buf bytes.Buffer
+
+	err := cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: &buf,
+	})
+
+	require.NoError(t, err)
+	require.JSONEq(t, `{"URL":"s3://s3lock-test/lock-obj","Locked":false}`, buf.String())
+}
+
+func TestStatusCmdLockFile(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	lockFile := filepath.Join(t.TempDir(), "lock.info")
+	err := os.WriteFile(lockFile, []byte(`{"Bucket":"s3lock-test","Key":"lock-obj","Id":"my-id","ETag":"\"my-etag\""}`), 0600)
+	require.NoError(t, err)
+
+	cmd := &subcmd.StatusCmd{
+		Target: lockFile,
+		JSON:   true,
+	}
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"Id":"my-id","AcquiredAt":"2025-01-01T10:02:00Z"}`)
+		resp.Header.Set("ETag", `"my-etag"`)
+		resp.Header.Set("Last-Modified", "Wed, 01 Jan 2025 10:02:00 GMT")
+		return resp, nil
+	})
+
+	var buf bytes.Buffer
+
+	err = cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: &buf,
+	})
+
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"URL": "s3://s3lock-test/lock-obj",
+		"Locked": true,
+		"Bucket": "s3lock-test",
+		"Key": "lock-obj",
+		"Id": "my-id",
+		"ETag": "\"my-etag\"",
+		"LastModified": "2025-01-01T10:02:00Z",
+		"AcquiredAt": "2025-01-01T10:02:00Z",
+		"LockFile": "`+lockFile+`",
+		"Matched": true
+	}`, buf.String())
+}
+
+func TestStatusCmdLockFileMismatch(t *testing.T) {
+	hc := &http.Client{}
+	httpmock.ActivateNonDefault(hc)
+	t.Cleanup(func() { httpmock.DeactivateNonDefault(hc) })
+
+	cfg, _ := config.LoadDefaultConfig(t.Context(), config.WithHTTPClient(hc))
+	s3cli := s3.NewFromConfig(cfg)
+
+	lockFile := filepath.Join(t.TempDir(), "lock.info")
+	err := os.WriteFile(lockFile, []byte(`{"Bucket":"s3lock-test","Key":"lock-obj","Id":"my-id","ETag":"\"my-etag\""}`), 0600)
+	require.NoError(t, err)
+
+	cmd := &subcmd.StatusCmd{
+		Target: lockFile,
+	}
+
+	httpmock.RegisterResponder(http.MethodGet, "https://s3lock-test.s3.us-east-1.amazonaws.com/lock-obj?x-id=GetObject", func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("If-Match") != "" {
+			return httpmock.NewStringResponse(http.StatusPreconditionFailed, ""), nil
+		}
+
+		resp := httpmock.NewStringResponse(http.StatusOK, `other-id`)
+		resp.Header.Set("ETag", `"other-etag"`)
+		resp.Header.Set("Last-Modified", "Wed, 01 Jan 2025 10:02:00 GMT")
+		return resp, nil
+	})
+
+	var buf bytes.Buffer
+
+	err = cmd.Run(&subcmd.Context{
+		S3:     s3cli,
+		Output: &buf,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, `url: s3://s3lock-test/lock-obj
+locked: true
+id: other-id
+etag: "other-etag"
+last modified: 2025-01-01T10:02:00Z
+lock file: `+lockFile+` (lock mismatch)
+`, buf.String())
+}
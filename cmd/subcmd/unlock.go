@@ -1,8 +1,11 @@
 package subcmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/winebarrel/s3lock"
 )
@@ -18,19 +21,39 @@ func (cmd *UnlockCmd) Run(cmdCtx *Context) error {
 		return err
 	}
 
-	lock, err := s3lock.NewLockFromJSON(cmdCtx.S3, j)
+	locks, err := cmdCtx.locksFromJSON(j)
 
 	if err != nil {
 		return err
 	}
 
-	err = lock.Unlock()
+	var errs []error
+	var held []*s3lock.Lock
 
-	if err != nil {
-		return err
+	// in the reverse order of acquisition
+	for _, lock := range slices.Backward(locks) {
+		if err := unlock(cmdCtx, lock); err != nil {
+			errs = append(errs, err)
+			held = append(held, lock)
+		}
+	}
+
+	if len(errs) > 0 {
+		if len(held) < len(locks) {
+			// keep only the locks that failed, so that the unlock can be retried
+			slices.Reverse(held)
+			j, err := json.Marshal(held)
+
+			if err == nil {
+				err = os.WriteFile(cmd.LockFile, j, 0644)
+			}
+
+			errs = append(errs, err)
+		}
+
+		return errors.Join(errs...)
 	}
 
-	fmt.Fprintf(cmdCtx.Output, "%s has been unlocked\n", lock) //nolint:errcheck
 	err = os.Remove(cmd.LockFile)
 
 	if err != nil {
@@ -41,3 +64,15 @@ func (cmd *UnlockCmd) Run(cmdCtx *Context) error {
 
 	return nil
 }
+
+func unlock(cmdCtx *Context, lock *s3lock.Lock) error {
+	err := lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmdCtx.Output, "%s has been unlocked\n", lock) //nolint:errcheck
+
+	return nil
+}
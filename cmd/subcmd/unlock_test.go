@@ -2,8 +2,11 @@ package subcmd_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
@@ -88,3 +91,49 @@ func TestUnlockCmdAlreadyUnlocked(t *testing.T) {
 	_, err = os.Stat(lockFile)
 	require.NoError(t, err)
 }
+
+func TestUnlockCmdPartialFailure(t *testing.T) {
+	dir := t.TempDir()
+	lockFile := filepath.Join(t.TempDir(), "lock-a.lock")
+
+	lockCmd := &subcmd.LockCmd{
+		URLs: []*url.URL{
+			{Scheme: "file", Path: dir + "/lock-a"},
+			{Scheme: "file", Path: dir + "/lock-b"},
+		},
+		Output: lockFile,
+	}
+
+	err := lockCmd.Run(&subcmd.Context{Output: io.Discard})
+	require.NoError(t, err)
+
+	// lock-a cannot be unlocked
+	lockA := filepath.Join(dir, "lock-a")
+	data, err := os.ReadFile(lockA)
+	require.NoError(t, err)
+	err = os.WriteFile(lockA, []byte(`{"Id":"other-id"}`), 0644)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	unlockCmd := &subcmd.UnlockCmd{LockFile: lockFile}
+	err = unlockCmd.Run(&subcmd.Context{Output: &buf})
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+	require.Equal(t, fmt.Sprintf("file://%s/lock-b has been unlocked\n", dir), buf.String())
+
+	// Only the lock that failed is left in the lock file
+	b, err := os.ReadFile(lockFile)
+	require.NoError(t, err)
+	var locks []map[string]any
+	err = json.Unmarshal(b, &locks)
+	require.NoError(t, err)
+	require.Len(t, locks, 1)
+	require.Equal(t, "lock-a", locks[0]["Key"])
+
+	// The unlock can be retried
+	err = os.WriteFile(lockA, data, 0644)
+	require.NoError(t, err)
+	buf.Reset()
+	err = unlockCmd.Run(&subcmd.Context{Output: &buf})
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("file://%s/lock-a has been unlocked\ndelete %s\n", dir, lockFile), buf.String())
+}
@@ -0,0 +1,165 @@
+// Package election provides leader election built on s3lock.
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/winebarrel/s3lock"
+)
+
+var ErrNoLeader = errors.New("no leader")
+
+// Election elects one leader among the candidates campaigning on the key of the object.
+// The identity of the leader is recorded as the description of the lock holder.
+type Election struct {
+	obj      *s3lock.Object
+	identity string
+	ttl      time.Duration
+}
+
+// New returns the election of the candidate with the identity.
+// The leader renews its lease of ttl every ttl/3, and loses the leadership if the lease would expire before the next renewal.
+// ttl must be positive, so that the lease of a crashed leader expires.
+func New(obj *s3lock.Object, identity string, ttl time.Duration) (*Election, error) {
+	if ttl/3 <= 0 {
+		return nil, fmt.Errorf("invalid ttl: %s", ttl)
+	}
+
+	e := &Election{
+		obj:      obj,
+		identity: identity,
+		ttl:      ttl,
+	}
+
+	return e, nil
+}
+
+// Campaign blocks until the candidate becomes the leader.
+// It waits like Object.LockWait with the options of the object, and fails with its error when ctx is done.
+func (e *Election) Campaign(ctx context.Context) (*Leadership, error) {
+	holder := s3lock.CurrentHolder()
+	holder.Description = e.identity
+	lock, err := e.obj.LockWait(ctx, s3lock.WithTTL(e.ttl), s3lock.WithHolder(holder))
+
+	if err != nil {
+		return nil, err
+	}
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	err = lock.KeepAlive(renewCtx, e.ttl/3)
+
+	if err != nil {
+		cancel()
+		lock.UnlockContext(context.WithoutCancel(ctx)) //nolint:errcheck
+		return nil, err
+	}
+
+	l := &Leadership{
+		lock:   lock,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		select {
+		case <-lock.Lost():
+		case <-renewCtx.Done():
+		}
+
+		close(l.done)
+	}()
+
+	return l, nil
+}
+
+// Leader returns the identity of the current leader, or ErrNoLeader if there is no leader.
+func (e *Election) Leader(ctx context.Context) (string, error) {
+	info, err := e.obj.Info(ctx)
+
+	if err != nil {
+		if errors.Is(err, s3lock.ErrNotLocked) {
+			return "", ErrNoLeader
+		}
+
+		return "", err
+	}
+
+	if info.Expired() {
+		return "", ErrNoLeader
+	}
+
+	if info.Holder == nil {
+		return "", nil
+	}
+
+	return info.Holder.Description, nil
+}
+
+// Observe polls the leader every interval and sends its identity whenever it changes,
+// or "" while there is no leader. The channel is closed when ctx is done.
+// A non-positive interval is replaced with s3lock.LockWaitInterval.
+func (e *Election) Observe(ctx context.Context, interval time.Duration) <-chan string {
+	if interval <= 0 {
+		interval = s3lock.LockWaitInterval
+	}
+
+	ch := make(chan string)
+
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last *string
+
+		for {
+			leader, err := e.Leader(ctx)
+
+			// keep the last leader on transient errors
+			if err == nil || errors.Is(err, ErrNoLeader) {
+				if last == nil || *last != leader {
+					select {
+					case ch <- leader:
+					case <-ctx.Done():
+						return
+					}
+
+					last = &leader
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return ch
+}
+
+// Leadership is held by the elected candidate until it resigns or loses the lease.
+type Leadership struct {
+	lock   *s3lock.Lock
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Lock returns the lock of the leadership, e.g., to get the fencing token of the term.
+func (l *Leadership) Lock() *s3lock.Lock {
+	return l.lock
+}
+
+// Done returns a channel that is closed when the leadership is lost or resigned.
+func (l *Leadership) Done() <-chan struct{} {
+	return l.done
+}
+
+// Resign stops renewing the lease and releases the leadership.
+func (l *Leadership) Resign(ctx context.Context) error {
+	l.cancel()
+	return l.lock.UnlockContext(ctx)
+}
@@ -0,0 +1,151 @@
+package election_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/election"
+	"github.com/winebarrel/s3lock/s3locktest"
+)
+
+func TestElection(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "leader", s3lock.WithWaitInterval(10*time.Millisecond))
+	a, err := election.New(obj, "a", time.Second)
+	require.NoError(t, err)
+	b, err := election.New(obj, "b", time.Second)
+	require.NoError(t, err)
+
+	_, err = a.Leader(t.Context())
+	require.ErrorIs(t, err, election.ErrNoLeader)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	leaders := a.Observe(ctx, 10*time.Millisecond)
+	require.Equal(t, "", <-leaders)
+
+	// a becomes the leader
+	la, err := a.Campaign(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "a", <-leaders)
+
+	leader, err := b.Leader(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "a", leader)
+
+	campaignCtx, campaignCancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer campaignCancel()
+	_, err = b.Campaign(campaignCtx)
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// b takes over after a resigns
+	done := make(chan *election.Leadership)
+
+	go func() {
+		lb, _ := b.Campaign(t.Context())
+		done <- lb
+	}()
+
+	err = la.Resign(t.Context())
+	require.NoError(t, err)
+	<-la.Done()
+	lb := <-done
+	require.NotNil(t, lb)
+
+	// "" may be observed in between
+	for leader := range leaders {
+		if leader == "b" {
+			break
+		}
+
+		require.Equal(t, "", leader)
+	}
+
+	err = lb.Resign(t.Context())
+	require.NoError(t, err)
+}
+
+func TestElectionLost(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "leader")
+	e, err := election.New(obj, "a", 300*time.Millisecond)
+	require.NoError(t, err)
+	l, err := e.Campaign(t.Context())
+	require.NoError(t, err)
+
+	// The lease is renewed
+	time.Sleep(500 * time.Millisecond)
+
+	select {
+	case <-l.Done():
+		require.Fail(t, "leadership lost")
+	default:
+	}
+
+	// Taken by another process
+	backend.Put("leader", []byte(`{"Id":"other-id"}`))
+
+	select {
+	case <-l.Done():
+	case <-time.After(time.Second):
+		require.Fail(t, "leadership not lost")
+	}
+}
+
+func TestElectionInvalidTTL(t *testing.T) {
+	obj := s3lock.NewWithBackend(s3locktest.NewBackend("s3lock-test"), "leader")
+
+	// The lease of a crashed leader must expire
+	_, err := election.New(obj, "a", 0)
+	require.ErrorContains(t, err, "invalid ttl: 0s")
+	_, err = election.New(obj, "a", 2*time.Nanosecond)
+	require.ErrorContains(t, err, "invalid ttl: 2ns")
+}
+
+func TestElectionObserveInvalidInterval(t *testing.T) {
+	obj := s3lock.NewWithBackend(s3locktest.NewBackend("s3lock-test"), "leader")
+	e, err := election.New(obj, "a", time.Second)
+	require.NoError(t, err)
+
+	// Polls at the default interval
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	leaders := e.Observe(ctx, 0)
+	require.Equal(t, "", <-leaders)
+
+	l, err := e.Campaign(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "a", <-leaders)
+
+	err = l.Resign(t.Context())
+	require.NoError(t, err)
+}
+
+func TestElectionLostBeforeExpiry(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "leader")
+	e, err := election.New(obj, "a", 600*time.Millisecond)
+	require.NoError(t, err)
+	l, err := e.Campaign(t.Context())
+	require.NoError(t, err)
+
+	// The leader cannot renew the lease
+	backend.InjectFailure(func(op s3locktest.Op, _ string) error {
+		if op == s3locktest.OpReplace {
+			return s3locktest.ErrInjected
+		}
+
+		return nil
+	})
+
+	select {
+	case <-l.Done():
+		// before a standby can take over
+		require.True(t, time.Now().Before(l.Lock().ExpiresAt()))
+	case <-time.After(time.Second):
+		require.Fail(t, "leadership not lost")
+	}
+}
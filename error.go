@@ -0,0 +1,58 @@
+package s3lock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LockHeldError is returned when the lock is held by another holder.
+// It satisfies errors.Is(err, ErrLockAlreadyHeld).
+type LockHeldError struct {
+	Id           string
+	ETag         string
+	LastModified time.Time
+	AcquiredAt   time.Time
+	ExpiresAt    time.Time
+	Holder       *Holder
+}
+
+func newLockHeldError(info *LockInfo) *LockHeldError {
+	return &LockHeldError{
+		Id:           info.Id,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+		AcquiredAt:   info.AcquiredAt,
+		ExpiresAt:    info.ExpiresAt,
+		Holder:       info.Holder,
+	}
+}
+
+func (e *LockHeldError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(ErrLockAlreadyHeld.Error())
+
+	if e.Holder != nil && e.Holder.Hostname != "" {
+		fmt.Fprintf(&sb, " by %s", e.Holder.Hostname)
+
+		if e.Holder.PID != 0 {
+			fmt.Fprintf(&sb, " (pid %d)", e.Holder.PID)
+		}
+	}
+
+	since := e.AcquiredAt
+
+	if since.IsZero() {
+		since = e.LastModified
+	}
+
+	if !since.IsZero() {
+		fmt.Fprintf(&sb, " since %s", since.UTC().Format("2006-01-02 15:04 MST"))
+	}
+
+	return sb.String()
+}
+
+func (e *LockHeldError) Is(target error) bool {
+	return target == ErrLockAlreadyHeld
+}
@@ -0,0 +1,70 @@
+package s3lock_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+)
+
+func TestLockHeldErrorMessage(t *testing.T) {
+	tt := []struct {
+		err      *s3lock.LockHeldError
+		expected string
+	}{
+		{
+			err:      &s3lock.LockHeldError{},
+			expected: "lock already held",
+		},
+		{
+			err: &s3lock.LockHeldError{
+				AcquiredAt: time.Date(2025, 1, 2, 10, 2, 3, 0, time.UTC),
+				Holder:     &s3lock.Holder{Hostname: "host-a", PID: 1234},
+			},
+			expected: "lock already held by host-a (pid 1234) since 2025-01-02 10:02 UTC",
+		},
+		{
+			err: &s3lock.LockHeldError{
+				LastModified: time.Date(2025, 1, 2, 19, 2, 3, 0, time.FixedZone("JST", 9*60*60)),
+				Holder:       &s3lock.Holder{Hostname: "host-a"},
+			},
+			expected: "lock already held by host-a since 2025-01-02 10:02 UTC",
+		},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.expected, tc.err.Error())
+		require.ErrorIs(t, tc.err, s3lock.ErrLockAlreadyHeld)
+	}
+}
+
+func TestLockHeldError(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	holder := &s3lock.Holder{Hostname: "host-a", PID: 1234}
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Minute), s3lock.WithHolder(holder))
+	require.NoError(t, err)
+
+	// Other clients get the current holder
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+	var heldErr *s3lock.LockHeldError
+	require.True(t, errors.As(err, &heldErr))
+	j, _ := lock.MarshalJSON()
+	require.Contains(t, string(j), heldErr.Id)
+	require.NotEmpty(t, heldErr.ETag)
+	require.False(t, heldErr.LastModified.IsZero())
+	require.True(t, lock.ExpiresAt().Equal(heldErr.ExpiresAt))
+	require.Equal(t, holder, heldErr.Holder)
+	require.Regexp(t, `^lock already held by host-a \(pid 1234\) since \d{4}-\d{2}-\d{2} \d{2}:\d{2} UTC$`, err.Error())
+
+	// Unlock
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
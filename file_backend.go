@@ -0,0 +1,237 @@
+package s3lock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Files of FileBackend itself are prefixed with it and never listed.
+const fileBackendPrefix = ".s3lock"
+
+// FileBackend stores lock objects as files under a directory.
+// Conditional operations are serialized with an advisory file lock,
+// so it is safe between processes on a single host, but not on network file systems.
+type FileBackend struct {
+	dir string
+}
+
+func NewFileBackend(dir string) *FileBackend {
+	return &FileBackend{
+		dir: dir,
+	}
+}
+
+func (b *FileBackend) Scheme() string {
+	return "file"
+}
+
+func (b *FileBackend) Bucket() string {
+	return b.dir
+}
+
+func (b *FileBackend) Create(_ context.Context, key string, data []byte) (string, error) {
+	var etag string
+
+	err := b.withLock(key, func(path string) error {
+		_, err := os.Stat(path)
+
+		if err == nil {
+			return ErrPreconditionFailed
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		etag, err = writeFile(path, data)
+		return err
+	})
+
+	return etag, err
+}
+
+func (b *FileBackend) Get(_ context.Context, key string, ifMatch string) (*Entry, error) {
+	var entry *Entry
+
+	err := b.withLock(key, func(path string) error {
+		var err error
+		entry, err = readFile(path, ifMatch)
+		return err
+	})
+
+	return entry, err
+}
+
+func (b *FileBackend) Replace(_ context.Context, key string, data []byte, ifMatch string) (string, error) {
+	var etag string
+
+	err := b.withLock(key, func(path string) error {
+		_, err := readFile(path, ifMatch)
+
+		if err != nil {
+			return err
+		}
+
+		etag, err = writeFile(path, data)
+		return err
+	})
+
+	return etag, err
+}
+
+func (b *FileBackend) Delete(_ context.Context, key string, ifMatch string) error {
+	return b.withLock(key, func(path string) error {
+		_, err := readFile(path, ifMatch)
+
+		if err != nil {
+			return err
+		}
+
+		return os.Remove(path)
+	})
+}
+
+func (b *FileBackend) List(_ context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+
+	err := filepath.WalkDir(b.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), fileBackendPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.dir, path)
+
+		if err != nil {
+			return err
+		}
+
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (b *FileBackend) withLock(key string, fn func(path string) error) error {
+	if !filepath.IsLocal(key) {
+		return fmt.Errorf("invalid key: %s", key)
+	}
+
+	err := os.MkdirAll(b.dir, 0o755)
+
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(b.dir, fileBackendPrefix), os.O_CREATE|os.O_RDWR, 0o644)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	err = lockFile(f)
+
+	if err != nil {
+		return err
+	}
+
+	defer unlockFile(f) //nolint:errcheck
+
+	return fn(filepath.Join(b.dir, filepath.FromSlash(key)))
+}
+
+func readFile(path string, ifMatch string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		Data:         data,
+		ETag:         fileETag(data),
+		LastModified: fi.ModTime(),
+	}
+
+	if ifMatch != "" && ifMatch != entry.ETag {
+		return nil, ErrPreconditionFailed
+	}
+
+	return entry, nil
+}
+
+// writeFile replaces the file atomically so that a crash never leaves a partial lock object.
+func writeFile(path string, data []byte) (string, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), fileBackendPrefix+"-*")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	_, err = tmp.Write(data)
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Rename(tmp.Name(), path)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fileETag(data), nil
+}
+
+func fileETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
@@ -0,0 +1,121 @@
+package s3lock_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+)
+
+func TestFileBackend(t *testing.T) {
+	backend := s3lock.NewFileBackend(t.TempDir())
+
+	// Create
+	etag, err := backend.Create(t.Context(), "dir/lock-obj", []byte("foo"))
+	require.NoError(t, err)
+	require.Regexp(t, `^"\w{32}"$`, etag)
+	_, err = backend.Create(t.Context(), "dir/lock-obj", []byte("bar"))
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+
+	// Get
+	entry, err := backend.Get(t.Context(), "dir/lock-obj", "")
+	require.NoError(t, err)
+	require.Equal(t, "foo", string(entry.Data))
+	require.Equal(t, etag, entry.ETag)
+	require.WithinDuration(t, time.Now(), entry.LastModified, 10*time.Second)
+	_, err = backend.Get(t.Context(), "dir/lock-obj", `"invalid"`)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	_, err = backend.Get(t.Context(), "not-found", "")
+	require.ErrorIs(t, err, s3lock.ErrObjectNotFound)
+
+	// Replace
+	_, err = backend.Replace(t.Context(), "dir/lock-obj", []byte("bar"), `"invalid"`)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	etag2, err := backend.Replace(t.Context(), "dir/lock-obj", []byte("bar"), etag)
+	require.NoError(t, err)
+	require.NotEqual(t, etag, etag2)
+	_, err = backend.Replace(t.Context(), "not-found", []byte("bar"), etag)
+	require.ErrorIs(t, err, s3lock.ErrObjectNotFound)
+
+	// List
+	_, err = backend.Create(t.Context(), "lock-obj2", []byte("baz"))
+	require.NoError(t, err)
+	keys, err := backend.List(t.Context(), "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"dir/lock-obj", "lock-obj2"}, keys)
+	keys, err = backend.List(t.Context(), "dir/")
+	require.NoError(t, err)
+	require.Equal(t, []string{"dir/lock-obj"}, keys)
+
+	// Delete
+	err = backend.Delete(t.Context(), "dir/lock-obj", etag)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	err = backend.Delete(t.Context(), "dir/lock-obj", etag2)
+	require.NoError(t, err)
+	err = backend.Delete(t.Context(), "dir/lock-obj", etag2)
+	require.ErrorIs(t, err, s3lock.ErrObjectNotFound)
+
+	// Invalid key
+	_, err = backend.Create(t.Context(), "../lock-obj", []byte("foo"))
+	require.EqualError(t, err, "invalid key: ../lock-obj")
+}
+
+func TestFileBackendLock(t *testing.T) {
+	dir := t.TempDir()
+	obj := s3lock.NewWithBackend(s3lock.NewFileBackend(dir), "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithFencingToken())
+	require.NoError(t, err)
+	require.Equal(t, "file://"+dir+"/lock-obj", lock.String())
+	require.Equal(t, uint64(1), lock.Token())
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Lock file
+	j, err := json.Marshal(lock)
+	require.NoError(t, err)
+	require.Contains(t, string(j), `"Scheme":"file"`)
+
+	lock2, err := s3lock.NewLockFromJSONWithResolver(j, func(scheme string, bucket string) (s3lock.Backend, error) {
+		require.Equal(t, "file", scheme)
+		require.Equal(t, dir, bucket)
+		return s3lock.NewFileBackend(bucket), nil
+	})
+
+	require.NoError(t, err)
+
+	// List
+	infos, err := s3lock.ListWithBackend(t.Context(), lock.Backend(), "")
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	require.Equal(t, "lock-obj", infos[0].Key)
+
+	// Unlock
+	err = lock2.Unlock()
+	require.NoError(t, err)
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+	_, err = os.Stat(filepath.Join(dir, "lock-obj"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestFileBackendTakeOver(t *testing.T) {
+	obj := s3lock.NewWithBackend(s3lock.NewFileBackend(t.TempDir()), "lock-obj")
+
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(500*time.Millisecond))
+	require.NoError(t, err)
+
+	time.Sleep(1 * time.Second)
+	lock2, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+	err = lock2.Unlock()
+	require.NoError(t, err)
+}
@@ -0,0 +1,16 @@
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || solaris || windows)
+
+package s3lock
+
+import (
+	"errors"
+	"os"
+)
+
+func lockFile(_ *os.File) error {
+	return errors.ErrUnsupported
+}
+
+func unlockFile(_ *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,169 @@
+// Package gcs provides the s3lock backend for Google Cloud Storage.
+package gcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	"github.com/winebarrel/s3lock"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+)
+
+// Backend stores lock objects in a GCS bucket using generation preconditions.
+// The ETag of a lock object is its generation number.
+type Backend struct {
+	gcs    *storage.Client
+	bucket string
+}
+
+var _ s3lock.Backend = (*Backend)(nil)
+
+func NewBackend(gcsClient *storage.Client, bucket string) *Backend {
+	return &Backend{
+		gcs:    gcsClient,
+		bucket: bucket,
+	}
+}
+
+func (b *Backend) Scheme() string {
+	return "gs"
+}
+
+func (b *Backend) Bucket() string {
+	return b.bucket
+}
+
+func (b *Backend) Create(ctx context.Context, key string, data []byte) (string, error) {
+	// ifGenerationMatch=0
+	obj := b.gcs.Bucket(b.bucket).Object(key).If(storage.Conditions{DoesNotExist: true})
+	return write(ctx, obj, data)
+}
+
+func (b *Backend) Get(ctx context.Context, key string, ifMatch string) (*s3lock.Entry, error) {
+	r, err := b.gcs.Bucket(b.bucket).Object(key).NewReader(ctx)
+
+	if err != nil {
+		return nil, gcsError(err)
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &s3lock.Entry{
+		Data:         data,
+		ETag:         strconv.FormatInt(r.Attrs.Generation, 10),
+		LastModified: r.Attrs.LastModified,
+	}
+
+	// The generation of the read content is compared instead of a read precondition,
+	// which is not supported by some emulators.
+	if ifMatch != "" && ifMatch != entry.ETag {
+		return nil, s3lock.ErrPreconditionFailed
+	}
+
+	return entry, nil
+}
+
+func (b *Backend) Replace(ctx context.Context, key string, data []byte, ifMatch string) (string, error) {
+	gen, err := parseGeneration(ifMatch)
+
+	if err != nil {
+		return "", err
+	}
+
+	obj := b.gcs.Bucket(b.bucket).Object(key).If(storage.Conditions{GenerationMatch: gen})
+	return write(ctx, obj, data)
+}
+
+func (b *Backend) Delete(ctx context.Context, key string, ifMatch string) error {
+	gen, err := parseGeneration(ifMatch)
+
+	if err != nil {
+		return err
+	}
+
+	obj := b.gcs.Bucket(b.bucket).Object(key).If(storage.Conditions{GenerationMatch: gen})
+	return gcsError(obj.Delete(ctx))
+}
+
+func (b *Backend) List(ctx context.Context, prefix string) ([]string, error) {
+	it := b.gcs.Bucket(b.bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	keys := []string{}
+
+	for {
+		attrs, err := it.Next()
+
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, attrs.Name)
+	}
+
+	return keys, nil
+}
+
+func write(ctx context.Context, obj *storage.ObjectHandle, data []byte) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := obj.NewWriter(ctx)
+	w.ContentType = "application/json"
+	_, err := w.Write(data)
+
+	if err != nil {
+		return "", gcsError(err)
+	}
+
+	err = w.Close()
+
+	if err != nil {
+		return "", gcsError(err)
+	}
+
+	return strconv.FormatInt(w.Attrs().Generation, 10), nil
+}
+
+func parseGeneration(etag string) (int64, error) {
+	gen, err := strconv.ParseInt(etag, 10, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid generation: %s", etag)
+	}
+
+	return gen, nil
+}
+
+func gcsError(err error) error {
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("%w: %w", s3lock.ErrObjectNotFound, err)
+	}
+
+	var apiErr *googleapi.Error
+
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", s3lock.ErrObjectNotFound, err)
+		case http.StatusPreconditionFailed:
+			return fmt.Errorf("%w: %w", s3lock.ErrPreconditionFailed, err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,178 @@
+package gcs_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/gcs"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+)
+
+func testNewGCSClient(t *testing.T) *storage.Client {
+	t.Helper()
+	server, err := fakestorage.NewServerWithOptions(fakestorage.Options{NoListener: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(server.Stop)
+	server.CreateBucketWithOpts(fakestorage.CreateBucketOpts{Name: "s3lock-test"})
+
+	return server.Client()
+}
+
+func TestBackend(t *testing.T) {
+	backend := gcs.NewBackend(testNewGCSClient(t), "s3lock-test")
+
+	// Create
+	gen, err := backend.Create(t.Context(), "lock-obj", []byte("foo"))
+	require.NoError(t, err)
+	require.Regexp(t, `^\d+$`, gen)
+	_, err = backend.Create(t.Context(), "lock-obj", []byte("bar"))
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+
+	// Get
+	entry, err := backend.Get(t.Context(), "lock-obj", "")
+	require.NoError(t, err)
+	require.Equal(t, "foo", string(entry.Data))
+	require.Equal(t, gen, entry.ETag)
+	_, err = backend.Get(t.Context(), "lock-obj", "1")
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	_, err = backend.Get(t.Context(), "not-found", "")
+	require.ErrorIs(t, err, s3lock.ErrObjectNotFound)
+
+	// Replace
+	_, err = backend.Replace(t.Context(), "lock-obj", []byte("bar"), "1")
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	gen2, err := backend.Replace(t.Context(), "lock-obj", []byte("bar"), gen)
+	require.NoError(t, err)
+	require.NotEqual(t, gen, gen2)
+
+	// List
+	_, err = backend.Create(t.Context(), "locks/lock-obj2", []byte("baz"))
+	require.NoError(t, err)
+	keys, err := backend.List(t.Context(), "locks/")
+	require.NoError(t, err)
+	require.Equal(t, []string{"locks/lock-obj2"}, keys)
+
+	// Delete
+	// NOTE: fake-gcs-server does not check ifGenerationMatch of deletion (see TestBackendDeleteGenerationMatch)
+	err = backend.Delete(t.Context(), "lock-obj", gen2)
+	require.NoError(t, err)
+	err = backend.Delete(t.Context(), "lock-obj", gen2)
+	require.ErrorIs(t, err, s3lock.ErrObjectNotFound)
+}
+
+// testGenerationMatchTransport checks ifGenerationMatch of deletion,
+// which fake-gcs-server does not, and records the sent generations.
+type testGenerationMatchTransport struct {
+	server      *fakestorage.Server
+	base        http.RoundTripper
+	generations []string
+}
+
+func (tr *testGenerationMatchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodDelete {
+		gen := req.URL.Query().Get("ifGenerationMatch")
+		tr.generations = append(tr.generations, gen)
+		name := path.Base(req.URL.Path)
+
+		if obj, err := tr.server.GetObject("s3lock-test", name); err == nil && gen != strconv.FormatInt(obj.Generation, 10) {
+			resp := &http.Response{
+				StatusCode: http.StatusPreconditionFailed,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"error":{"code":412,"message":"conditionNotMet"}}`)),
+				Request:    req,
+			}
+
+			return resp, nil
+		}
+	}
+
+	return tr.base.RoundTrip(req)
+}
+
+func TestBackendDeleteGenerationMatch(t *testing.T) {
+	server, err := fakestorage.NewServerWithOptions(fakestorage.Options{NoListener: true})
+	require.NoError(t, err)
+	t.Cleanup(server.Stop)
+	server.CreateBucketWithOpts(fakestorage.CreateBucketOpts{Name: "s3lock-test"})
+	tr := &testGenerationMatchTransport{server: server, base: server.HTTPClient().Transport}
+	gcsCli, err := storage.NewClient(t.Context(), option.WithHTTPClient(&http.Client{Transport: tr}), option.WithCredentials(&google.Credentials{}))
+	require.NoError(t, err)
+	backend := gcs.NewBackend(gcsCli, "s3lock-test")
+
+	gen, err := backend.Create(t.Context(), "lock-obj", []byte("foo"))
+	require.NoError(t, err)
+	gen2, err := backend.Replace(t.Context(), "lock-obj", []byte("bar"), gen)
+	require.NoError(t, err)
+
+	// Deleted only on the matching generation
+	err = backend.Delete(t.Context(), "lock-obj", gen)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	_, err = backend.Get(t.Context(), "lock-obj", gen2)
+	require.NoError(t, err)
+	err = backend.Delete(t.Context(), "lock-obj", gen2)
+	require.NoError(t, err)
+	require.Equal(t, []string{gen, gen2}, tr.generations)
+}
+
+func TestLock(t *testing.T) {
+	gcsCli := testNewGCSClient(t)
+	obj := s3lock.NewWithBackend(gcs.NewBackend(gcsCli, "s3lock-test"), "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithFencingToken())
+	require.NoError(t, err)
+	require.Equal(t, "gs://s3lock-test/lock-obj", lock.String())
+	require.Equal(t, uint64(1), lock.Token())
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Lock file
+	j, err := json.Marshal(lock)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Scheme":"gs","Bucket":"s3lock-test","Key":"lock-obj","Id":"\w{8}-\w{4}-\w{4}-\w{4}-\w{12}","ETag":"\d+","Token":1}`, string(j))
+
+	lock2, err := s3lock.NewLockFromJSONWithResolver(j, func(_ string, bucket string) (s3lock.Backend, error) {
+		return gcs.NewBackend(gcsCli, bucket), nil
+	})
+
+	require.NoError(t, err)
+
+	// Unlock
+	err = lock2.Unlock()
+	require.NoError(t, err)
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+	_, err = obj.Info(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrNotLocked)
+}
+
+func TestLockTakeOver(t *testing.T) {
+	obj := s3lock.NewWithBackend(gcs.NewBackend(testNewGCSClient(t), "s3lock-test"), "lock-obj")
+
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(500*time.Millisecond))
+	require.NoError(t, err)
+
+	time.Sleep(1 * time.Second)
+	lock2, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+	err = lock2.Unlock()
+	require.NoError(t, err)
+}
@@ -0,0 +1,33 @@
+package s3lock
+
+import (
+	"os"
+	"os/user"
+)
+
+// Holder describes who holds the lock.
+type Holder struct {
+	Hostname    string `json:",omitempty"`
+	PID         int    `json:",omitempty"`
+	User        string `json:",omitempty"`
+	Description string `json:",omitempty"`
+}
+
+// CurrentHolder returns the holder information of the current process.
+func CurrentHolder() *Holder {
+	h := &Holder{
+		PID: os.Getpid(),
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		h.Hostname = hostname
+	}
+
+	if u, err := user.Current(); err == nil {
+		h.User = u.Username
+	} else {
+		h.User = os.Getenv("USER")
+	}
+
+	return h
+}
@@ -0,0 +1,50 @@
+package s3lock_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+)
+
+func TestCurrentHolder(t *testing.T) {
+	hostname, _ := os.Hostname()
+	h := s3lock.CurrentHolder()
+	require.Equal(t, hostname, h.Hostname)
+	require.Equal(t, os.Getpid(), h.PID)
+	require.NotEmpty(t, h.User)
+	require.Empty(t, h.Description)
+}
+
+func TestLockWithHolder(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	holder := &s3lock.Holder{Hostname: "host-a", PID: 1234, User: "scott", Description: "nightly job"}
+
+	// Lock
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Minute), s3lock.WithHolder(holder))
+	require.NoError(t, err)
+
+	// Refresh keeps the holder
+	err = lock.Refresh(t.Context(), time.Minute)
+	require.NoError(t, err)
+
+	// Confirm that the lock object has the holder
+	body, err := testGetObject(t, s3cli, "s3lock-test", "lock-obj")
+	require.NoError(t, err)
+	var lease struct {
+		Holder *s3lock.Holder
+	}
+	err = json.Unmarshal([]byte(body), &lease)
+	require.NoError(t, err)
+	require.Equal(t, holder, lease.Holder)
+
+	// Unlock
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
@@ -0,0 +1,87 @@
+package s3lock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// LockInfo is the current state of the lock object.
+type LockInfo struct {
+	Bucket       string
+	Key          string
+	Id           string
+	ETag         string
+	LastModified time.Time
+	AcquiredAt   time.Time `json:",omitzero"`
+	ExpiresAt    time.Time `json:",omitzero"`
+	Holder       *Holder   `json:",omitempty"`
+	Owner        string    `json:",omitempty"`
+	HoldCount    int       `json:",omitzero"`
+}
+
+func (info *LockInfo) Expired() bool {
+	return (&lockBody{ExpiresAt: info.ExpiresAt}).expired(time.Now())
+}
+
+// HeldSince returns the acquisition time, or the last modified time for locks written by older versions.
+func (info *LockInfo) HeldSince() time.Time {
+	if info.AcquiredAt.IsZero() {
+		return info.LastModified
+	}
+
+	return info.AcquiredAt
+}
+
+// Info returns ErrNotLocked if the lock object does not exist.
+func (obj *Object) Info(ctx context.Context) (*LockInfo, error) {
+	entry, err := obj.backend.Get(ctx, obj.key, "")
+
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil, ErrNotLocked
+		}
+
+		return nil, err
+	}
+
+	body := parseLockBody(entry.Data)
+
+	info := &LockInfo{
+		Bucket:       obj.backend.Bucket(),
+		Key:          obj.key,
+		Id:           body.Id,
+		ETag:         entry.ETag,
+		LastModified: entry.LastModified,
+		AcquiredAt:   body.AcquiredAt,
+		ExpiresAt:    body.ExpiresAt,
+		Holder:       body.Holder,
+		Owner:        body.Owner,
+		HoldCount:    body.HoldCount,
+	}
+
+	return info, nil
+}
+
+// ForceUnlock deletes the lock object regardless of its holder, but only if its ETag matches,
+// so that a lock re-acquired in the meantime is never removed.
+func (obj *Object) ForceUnlock(ctx context.Context, etag string) error {
+	err := obj.backend.Delete(ctx, obj.key, etag)
+
+	switch {
+	case errors.Is(err, ErrObjectNotFound):
+		return ErrNotLocked
+	case errors.Is(err, ErrPreconditionFailed):
+		return ErrLockMismatch
+	}
+
+	return err
+}
+
+// Validate returns nil if the lock is still held.
+func (l *Lock) Validate(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.validate(ctx)
+	return err
+}
@@ -0,0 +1,100 @@
+package s3lock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+)
+
+func TestInfo(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	holder := &s3lock.Holder{Hostname: "host-a", PID: 1234}
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Not locked
+	_, err := obj.Info(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrNotLocked)
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Minute), s3lock.WithHolder(holder))
+	require.NoError(t, err)
+
+	info, err := obj.Info(t.Context())
+	require.NoError(t, err)
+	require.Regexp(t, `^\w{8}-\w{4}-\w{4}-\w{4}-\w{12}$`, info.Id)
+	require.Regexp(t, `^"\w{32}"$`, info.ETag)
+	require.WithinDuration(t, time.Now(), info.LastModified, 10*time.Second)
+	require.WithinDuration(t, time.Now(), info.AcquiredAt, 10*time.Second)
+	require.True(t, lock.ExpiresAt().Equal(info.ExpiresAt))
+	require.False(t, info.Expired())
+	require.Equal(t, holder, info.Holder)
+
+	// Unlock
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(500*time.Millisecond))
+	require.NoError(t, err)
+	err = lock.Validate(t.Context())
+	require.NoError(t, err)
+
+	// Other clients take over the expired lock
+	time.Sleep(1 * time.Second)
+	lock2, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+	err = lock.Validate(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+
+	// Unlock
+	err = lock2.Unlock()
+	require.NoError(t, err)
+	err = lock2.Validate(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+}
+
+func TestForceUnlock(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+	info, err := obj.Info(t.Context())
+	require.NoError(t, err)
+
+	// Unlock and lock again
+	err = lock.Unlock()
+	require.NoError(t, err)
+	lock, err = obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	// The re-acquired lock is not removed
+	err = obj.ForceUnlock(t.Context(), info.ETag)
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+
+	// Force unlock
+	info, err = obj.Info(t.Context())
+	require.NoError(t, err)
+	err = obj.ForceUnlock(t.Context(), info.ETag)
+	require.NoError(t, err)
+
+	// Not locked
+	err = obj.ForceUnlock(t.Context(), info.ETag)
+	require.ErrorIs(t, err, s3lock.ErrNotLocked)
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+}
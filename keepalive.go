@@ -0,0 +1,75 @@
+package s3lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KeepAlive refreshes the lease with the current TTL every interval in the background
+// until ctx is done, the lock is unlocked, or the lock is lost.
+// The lock is reported lost as soon as the lease would expire before the next renewal.
+func (l *Lock) KeepAlive(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid keepalive interval: %s", interval)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !l.renew(ctx, interval) {
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (l *Lock) renew(ctx context.Context, interval time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.unlocked {
+		return false
+	}
+
+	err := l.refresh(ctx, l.ttl)
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrLockMismatch) || errors.Is(err, ErrAlreadyUnlocked):
+		l.logger.Warn("lock lost", "url", l.String(), "error", err)
+		l.markLost()
+		return false
+	case ctx.Err() != nil:
+		return false
+	case !l.expiresAt.IsZero() && !time.Now().Add(interval).Before(l.expiresAt):
+		// the lease expires before the next renewal, and another contender may take it over
+		l.logger.Warn("lock lost", "url", l.String(), "error", err)
+		l.markLost()
+		return false
+	}
+
+	// retry at the next interval
+	l.logger.Warn("failed to renew lease", "url", l.String(), "error", err)
+	return true
+}
+
+func (l *Lock) markLost() {
+	l.lostOnce.Do(func() { close(l.lost) })
+}
+
+// Lost returns a channel that is closed when KeepAlive finds the lock gone or mismatched.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
@@ -0,0 +1,115 @@
+package s3lock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/s3locktest"
+)
+
+func TestKeepAlive(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(1*time.Second))
+	require.NoError(t, err)
+	err = lock.KeepAlive(t.Context(), 200*time.Millisecond)
+	require.NoError(t, err)
+
+	// Other clients cannot take over the lock being kept alive
+	time.Sleep(2 * time.Second)
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Unlock
+	err = lock.Unlock()
+	require.NoError(t, err)
+
+	// Unlocking does not mean that the lock has been lost
+	select {
+	case <-lock.Lost():
+		t.Fatal("lock should not be lost")
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestKeepAliveLockLost(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(1*time.Second))
+	require.NoError(t, err)
+	err = lock.KeepAlive(t.Context(), 200*time.Millisecond)
+	require.NoError(t, err)
+
+	// Delete the lock object behind the holder's back
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	select {
+	case <-lock.Lost():
+	case <-time.After(2 * time.Second):
+		t.Fatal("lock should be lost")
+	}
+
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+}
+
+func TestKeepAliveInvalidInterval(t *testing.T) {
+	obj, _ := s3locktest.NewObject("lock-obj")
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Second))
+	require.NoError(t, err)
+
+	err = lock.KeepAlive(t.Context(), 0)
+	require.ErrorContains(t, err, "invalid keepalive interval: 0s")
+}
+
+func TestKeepAliveConcurrentRead(t *testing.T) {
+	obj, _ := s3locktest.NewObject("lock-obj")
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Second), s3lock.WithFencingToken())
+	require.NoError(t, err)
+	err = lock.KeepAlive(t.Context(), time.Millisecond)
+	require.NoError(t, err)
+
+	// Read the fields renewed by KeepAlive (run with -race)
+	for deadline := time.Now().Add(100 * time.Millisecond); time.Now().Before(deadline); {
+		_ = lock.ExpiresAt()
+		_ = lock.Token()
+		_, err := lock.MarshalJSON()
+		require.NoError(t, err)
+	}
+
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
+
+func TestKeepAliveLostBeforeExpiry(t *testing.T) {
+	obj, backend := s3locktest.NewObject("lock-obj")
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Second))
+	require.NoError(t, err)
+	backend.InjectFailure(func(op s3locktest.Op, _ string) error {
+		if op == s3locktest.OpReplace {
+			return s3locktest.ErrInjected
+		}
+
+		return nil
+	})
+	err = lock.KeepAlive(t.Context(), 400*time.Millisecond)
+	require.NoError(t, err)
+
+	// The second renewal fails when the lease would expire before the third
+	select {
+	case <-lock.Lost():
+		require.True(t, time.Now().Before(lock.ExpiresAt()))
+	case <-time.After(time.Second):
+		t.Fatal("lock should be lost before it expires")
+	}
+}
@@ -0,0 +1,43 @@
+package s3lock
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// List returns the lock objects under the prefix, skipping companion objects such as "<key>.token" and "<key>.queue/".
+func List(ctx context.Context, s3Client S3ListAPI, bucket string, prefix string) ([]*LockInfo, error) {
+	return ListWithBackend(ctx, NewS3Backend(s3Client, bucket), prefix)
+}
+
+func ListWithBackend(ctx context.Context, backend Backend, prefix string) ([]*LockInfo, error) {
+	keys, err := backend.List(ctx, prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []*LockInfo{}
+
+	for _, key := range keys {
+		if strings.HasSuffix(key, tokenKeySuffix) || strings.Contains(key, queueKeySuffix) {
+			continue
+		}
+
+		info, err := NewWithBackend(backend, key).Info(ctx)
+
+		if err != nil {
+			if errors.Is(err, ErrNotLocked) {
+				// unlocked after listing
+				continue
+			}
+
+			return nil, err
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
@@ -0,0 +1,40 @@
+package s3lock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+)
+
+func TestList(t *testing.T) {
+	s3cli := testNewS3Client(t)
+
+	for _, key := range []string{"list-test/lock-a", "list-test/lock-a.token", "list-test/lock-b", "list-test/lock-c"} {
+		testDeleteObject(t, s3cli, "s3lock-test", key)
+	}
+
+	// Lock
+	lockA, err := s3lock.New(s3cli, "s3lock-test", "list-test/lock-a").Lock(t.Context(), s3lock.WithFencingToken())
+	require.NoError(t, err)
+	lockB, err := s3lock.New(s3cli, "s3lock-test", "list-test/lock-b").Lock(t.Context(), s3lock.WithTTL(time.Minute))
+	require.NoError(t, err)
+	testPutObject(t, s3cli, "s3lock-test", "list-test/lock-c", "legacy-id")
+
+	infos, err := s3lock.List(t.Context(), s3cli, "s3lock-test", "list-test/")
+	require.NoError(t, err)
+	require.Len(t, infos, 3)
+
+	require.Equal(t, "s3lock-test", infos[0].Bucket)
+	require.Equal(t, "list-test/lock-a", infos[0].Key)
+	j, _ := lockA.MarshalJSON()
+	require.Contains(t, string(j), infos[0].Id)
+
+	require.Equal(t, "list-test/lock-b", infos[1].Key)
+	require.True(t, lockB.ExpiresAt().Equal(infos[1].ExpiresAt))
+
+	require.Equal(t, "list-test/lock-c", infos[2].Key)
+	require.Equal(t, "legacy-id", infos[2].Id)
+	require.True(t, infos[2].HeldSince().Equal(infos[2].LastModified))
+}
@@ -5,136 +5,286 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
+	"log/slog"
 	"sync"
 	"time"
-
-	"github.com/aws/aws-sdk-go-v2/aws"
-	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/smithy-go"
-	"github.com/google/uuid"
 )
 
 var (
 	ErrLockAlreadyHeld = errors.New("lock already held")
 	ErrAlreadyUnlocked = errors.New("already unlocked")
 	ErrLockMismatch    = errors.New("lock mismatch")
+	ErrNotLocked       = errors.New("not locked")
 )
 
 type Object struct {
-	s3     *s3.Client
-	bucket string
-	key    string
+	backend Backend
+	key     string
+	opts    []Option
+}
+
+func New(s3Client S3API, bucket string, key string, opts ...Option) *Object {
+	return NewWithBackend(newS3BackendWithOptions(s3Client, bucket, opts), key, opts...)
+}
+
+func newS3BackendWithOptions(s3Client S3API, bucket string, opts []Option) *S3Backend {
+	o := newOptions(opts)
+	backend := NewS3Backend(s3Client, bucket)
+	backend.sse = o.sse
+	backend.sseKMSKeyID = o.sseKMSKeyID
+	backend.metadata = o.metadata
+
+	return backend
 }
 
-func New(s3Client *s3.Client, bucket string, key string) *Object {
+// NewWithBackend returns the lock object of the key.
+// The options are the defaults of Lock/LockWait.
+func NewWithBackend(backend Backend, key string, opts ...Option) *Object {
 	obj := &Object{
-		s3:     s3Client,
-		bucket: bucket,
-		key:    key,
+		backend: backend,
+		key:     key,
+		opts:    opts,
 	}
 
 	return obj
 }
 
-func (obj *Object) Lock(ctx context.Context) (*Lock, error) {
-	id := uuid.NewString()
+func (obj *Object) Lock(ctx context.Context, opts ...Option) (*Lock, error) {
+	return obj.lock(ctx, obj.options(opts))
+}
+
+func (obj *Object) lock(ctx context.Context, o *options) (*Lock, error) {
+	body := newLockBody(o.idGenerator(), o.ttl)
+	body.Holder = o.holder
 
-	input := &s3.PutObjectInput{
-		Body:        strings.NewReader(id),
-		Bucket:      aws.String(obj.bucket),
-		Key:         aws.String(obj.key),
-		IfNoneMatch: aws.String("*"),
+	if o.owner != "" {
+		body.Owner = o.owner
+		body.HoldCount = 1
 	}
 
-	output, err := obj.s3.PutObject(ctx, input)
+	data, err := json.Marshal(body)
 
 	if err != nil {
-		var (
-			opeErr  *smithy.OperationError
-			respErr *awshttp.ResponseError
-		)
+		return nil, err
+	}
+
+	var etag string
+	var held *lockBody
 
-		if errors.As(err, &opeErr) && errors.As(opeErr, &respErr) &&
-			respErr.Response.StatusCode == http.StatusPreconditionFailed {
-			return nil, ErrLockAlreadyHeld
+	for {
+		held = body
+		etag, err = obj.backend.Create(ctx, obj.key, data)
+
+		if errors.Is(err, ErrPreconditionFailed) {
+			etag, held, err = obj.takeOver(ctx, body, data, o)
+		}
+
+		if !errors.Is(err, errLockChanged) {
+			break
 		}
+	}
 
+	if err != nil {
 		return nil, err
 	}
 
 	l := &Lock{
-		s3:     obj.s3,
-		bucket: obj.bucket,
-		key:    obj.key,
-		id:     id,
-		etag:   aws.ToString(output.ETag),
+		backend:   obj.backend,
+		key:       obj.key,
+		id:        held.Id,
+		etag:      etag,
+		ttl:       o.ttl,
+		expiresAt: held.ExpiresAt,
+		owner:     held.Owner,
+		logger:    o.logger,
+		lost:      make(chan struct{}),
+	}
+
+	if o.fencing {
+		if held.holdCount() > 1 {
+			// the token issued to the first hold of the owner
+			l.token, _, err = getCounter(ctx, obj.backend, obj.key+tokenKeySuffix)
+		} else {
+			err = l.issueToken(ctx)
+		}
+
+		if err != nil {
+			if !errors.Is(err, ErrLockAlreadyHeld) {
+				l.UnlockContext(ctx) //nolint:errcheck
+			}
+
+			return nil, err
+		}
 	}
 
 	return l, nil
 }
 
-type Lock struct {
-	mu       sync.Mutex
-	unlocked bool
-	s3       *s3.Client
-	bucket   string
-	key      string
-	id       string
-	etag     string
-}
+// errLockChanged is returned by takeOver when the lock was changed by another hold of the owner,
+// so that the acquisition is retried.
+var errLockChanged = errors.New("lock changed")
 
-func (l *Lock) String() string {
-	return fmt.Sprintf("s3://%s/%s", l.bucket, l.key)
-}
+// takeOver returns the body of the lock that has been acquired.
+func (obj *Object) takeOver(ctx context.Context, body *lockBody, data []byte, o *options) (string, *lockBody, error) {
+	current, err := obj.Info(ctx)
 
-func (l *Lock) validate(ctx context.Context) error {
-	if l.unlocked {
-		return ErrAlreadyUnlocked
+	if err != nil {
+		if errors.Is(err, ErrNotLocked) {
+			// unlocked after the first attempt
+			return "", nil, errLockChanged
+		}
+
+		return "", nil, err
 	}
 
-	input := &s3.GetObjectInput{
-		Bucket:  aws.String(l.bucket),
-		Key:     aws.String(l.key),
-		IfMatch: aws.String(l.etag),
+	if !current.Expired() {
+		if o.owner != "" && current.Owner == o.owner {
+			return obj.reenter(ctx, current, o)
+		}
+
+		return "", nil, newLockHeldError(current)
 	}
 
-	output, err := l.s3.GetObject(ctx, input)
+	// Overwrite the expired lock only if it has not been changed since it was read,
+	// so that only one of the contenders can take it over.
+	etag, err := obj.backend.Replace(ctx, obj.key, data, current.ETag)
+
+	if errors.Is(err, ErrObjectNotFound) || errors.Is(err, ErrPreconditionFailed) {
+		return "", nil, obj.lostTakeOver(ctx, o)
+	}
 
 	if err != nil {
-		var (
-			opeErr  *smithy.OperationError
-			respErr *awshttp.ResponseError
-		)
-
-		if errors.As(err, &opeErr) && errors.As(opeErr, &respErr) {
-			switch respErr.Response.StatusCode {
-			case http.StatusNotFound:
-				return ErrAlreadyUnlocked
-			case http.StatusPreconditionFailed:
-				return ErrLockMismatch
-			}
+		return "", nil, err
+	}
+
+	o.logger.Info("took over expired lock", "url", backendURL(obj.backend, obj.key), "previous_id", current.Id, "expired_at", current.ExpiresAt)
+
+	return etag, body, nil
+}
+
+// lostTakeOver returns the error of a takeover lost to another contender with the lock that won it.
+func (obj *Object) lostTakeOver(ctx context.Context, o *options) error {
+	current, err := obj.Info(ctx)
+
+	if err != nil {
+		if errors.Is(err, ErrNotLocked) {
+			return errLockChanged
 		}
 
 		return err
 	}
 
-	defer output.Body.Close() //nolint:errcheck
+	if o.owner != "" && current.Owner == o.owner && !current.Expired() {
+		// taken over by another hold of the owner
+		return errLockChanged
+	}
+
+	return newLockHeldError(current)
+}
 
-	b, err := io.ReadAll(output.Body)
+// reenter increments the hold count of the lock held by the same owner.
+func (obj *Object) reenter(ctx context.Context, current *LockInfo, o *options) (string, *lockBody, error) {
+	body := &lockBody{
+		Id:         current.Id,
+		AcquiredAt: current.AcquiredAt,
+		ExpiresAt:  current.ExpiresAt,
+		Holder:     current.Holder,
+		Owner:      current.Owner,
+		HoldCount:  max(current.HoldCount, 1) + 1,
+	}
+
+	// extend the lease, but never shorten it for the outer holds
+	if expiresAt := time.Now().UTC().Add(o.ttl); o.ttl > 0 && !body.ExpiresAt.IsZero() && expiresAt.After(body.ExpiresAt) {
+		body.ExpiresAt = expiresAt
+	}
+
+	data, err := json.Marshal(body)
 
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
-	if string(b) != l.id {
-		return ErrLockMismatch
+	etag, err := obj.backend.Replace(ctx, obj.key, data, current.ETag)
+
+	if errors.Is(err, ErrObjectNotFound) || errors.Is(err, ErrPreconditionFailed) {
+		return "", nil, errLockChanged
 	}
 
-	return nil
+	if err != nil {
+		return "", nil, err
+	}
+
+	return etag, body, nil
+}
+
+type Lock struct {
+	mu        sync.Mutex
+	unlocked  bool
+	backend   Backend
+	key       string
+	id        string
+	etag      string
+	ttl       time.Duration
+	expiresAt time.Time
+	token     uint64
+	owner     string
+	logger    *slog.Logger
+	lost      chan struct{}
+	lostOnce  sync.Once
+}
+
+func (l *Lock) String() string {
+	return backendURL(l.backend, l.key)
+}
+
+func (l *Lock) Backend() Backend {
+	return l.backend
+}
+
+func (l *Lock) Bucket() string {
+	return l.backend.Bucket()
+}
+
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// ExpiresAt returns the zero time if the lock has no TTL.
+func (l *Lock) ExpiresAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.expiresAt
+}
+
+func (l *Lock) validate(ctx context.Context) (*lockBody, error) {
+	if l.unlocked {
+		return nil, ErrAlreadyUnlocked
+	}
+
+	etag := l.etag
+
+	if l.owner != "" {
+		// the other holds of the owner change the ETag
+		etag = ""
+	}
+
+	entry, err := l.backend.Get(ctx, l.key, etag)
+
+	if err != nil {
+		return nil, lockError(err)
+	}
+
+	body := parseLockBody(entry.Data)
+
+	if body.Id != l.id {
+		return nil, ErrLockMismatch
+	}
+
+	if l.owner != "" {
+		l.etag = entry.ETag
+	}
+
+	return body, nil
 }
 
 func (l *Lock) Unlock() error {
@@ -145,44 +295,151 @@ func (l *Lock) UnlockContext(ctx context.Context) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if err := l.validate(ctx); err != nil {
+	for {
+		current, err := l.validate(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if current.holdCount() > 1 {
+			// release one of the holds of the owner
+			current.HoldCount--
+			err = l.replace(ctx, current)
+		} else {
+			err = l.backend.Delete(ctx, l.key, l.etag)
+		}
+
+		if l.owner != "" && errors.Is(err, ErrPreconditionFailed) {
+			// changed by another hold of the owner
+			continue
+		}
+
+		if err != nil {
+			return lockError(err)
+		}
+
+		l.unlocked = true
+
+		return nil
+	}
+}
+
+func (l *Lock) replace(ctx context.Context, body *lockBody) error {
+	data, err := json.Marshal(body)
+
+	if err != nil {
 		return err
 	}
 
-	input := &s3.DeleteObjectInput{
-		Bucket:  aws.String(l.bucket),
-		Key:     aws.String(l.key),
-		IfMatch: aws.String(l.etag),
+	etag, err := l.backend.Replace(ctx, l.key, data, l.etag)
+
+	if err != nil {
+		return err
 	}
 
-	_, err := l.s3.DeleteObject(ctx, input)
+	l.etag = etag
 
-	if err == nil {
-		l.unlocked = true
+	return nil
+}
+
+// Refresh rewrites the lock with the expiry ttl from now.
+// ttl must be positive, so that the lease of a crashed holder expires.
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("invalid ttl: %s", ttl)
 	}
 
-	return err
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.refresh(ctx, ttl)
+}
+
+func (l *Lock) refresh(ctx context.Context, ttl time.Duration) error {
+	for {
+		current, err := l.validate(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		body := newLockBody(l.id, ttl)
+		body.Holder = current.Holder
+		body.Owner = current.Owner
+		body.HoldCount = current.HoldCount
+
+		if !current.AcquiredAt.IsZero() {
+			body.AcquiredAt = current.AcquiredAt
+		}
+
+		// never shorten the lease for the other holds of the owner
+		if l.owner != "" && !body.ExpiresAt.IsZero() && (current.ExpiresAt.IsZero() || current.ExpiresAt.After(body.ExpiresAt)) {
+			body.ExpiresAt = current.ExpiresAt
+		}
+
+		err = l.replace(ctx, body)
+
+		if l.owner != "" && errors.Is(err, ErrPreconditionFailed) {
+			// changed by another hold of the owner
+			continue
+		}
+
+		if err != nil {
+			return lockError(err)
+		}
+
+		l.ttl = ttl
+		l.expiresAt = body.ExpiresAt
+
+		return nil
+	}
 }
 
 type lockJSON struct {
-	Bucket string
-	Key    string
-	Id     string
-	ETag   string
+	// Scheme is omitted for S3 to keep compatibility with older lock files.
+	Scheme    string `json:",omitempty"`
+	Bucket    string
+	Key       string
+	Id        string
+	ETag      string
+	TTL       time.Duration `json:",omitzero"`
+	ExpiresAt time.Time     `json:",omitzero"`
+	Token     uint64        `json:",omitzero"`
+	Owner     string        `json:",omitempty"`
 }
 
 func (l *Lock) MarshalJSON() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	j := &lockJSON{
-		Bucket: l.bucket,
-		Key:    l.key,
-		Id:     l.id,
-		ETag:   l.etag,
+		Bucket:    l.backend.Bucket(),
+		Key:       l.key,
+		Id:        l.id,
+		ETag:      l.etag,
+		TTL:       l.ttl,
+		ExpiresAt: l.expiresAt,
+		Token:     l.token,
+		Owner:     l.owner,
+	}
+
+	if scheme := l.backend.Scheme(); scheme != "s3" {
+		j.Scheme = scheme
 	}
 
 	return json.Marshal(j)
 }
 
-func NewLockFromJSON(s3Client *s3.Client, data []byte) (*Lock, error) {
+func NewLockFromJSON(s3Client S3API, data []byte) (*Lock, error) {
+	return NewLockFromJSONWithResolver(data, func(_ string, bucket string) (Backend, error) {
+		return NewS3Backend(s3Client, bucket), nil
+	})
+}
+
+// BackendResolver returns the backend of the scheme and the bucket recorded in a lock file.
+type BackendResolver func(scheme string, bucket string) (Backend, error)
+
+func NewLockFromJSONWithResolver(data []byte, resolve BackendResolver) (*Lock, error) {
 	j := lockJSON{}
 	err := json.Unmarshal(data, &j)
 
@@ -190,25 +447,54 @@ func NewLockFromJSON(s3Client *s3.Client, data []byte) (*Lock, error) {
 		return nil, err
 	}
 
+	if j.Scheme == "" {
+		j.Scheme = "s3"
+	}
+
+	backend, err := resolve(j.Scheme, j.Bucket)
+
+	if err != nil {
+		return nil, err
+	}
+
 	l := &Lock{
-		s3:     s3Client,
-		bucket: j.Bucket,
-		key:    j.Key,
-		id:     j.Id,
-		etag:   j.ETag,
+		backend:   backend,
+		key:       j.Key,
+		id:        j.Id,
+		etag:      j.ETag,
+		ttl:       j.TTL,
+		expiresAt: j.ExpiresAt,
+		token:     j.Token,
+		owner:     j.Owner,
+		logger:    slog.New(slog.DiscardHandler),
+		lost:      make(chan struct{}),
 	}
 
 	return l, nil
 }
 
+// LockWaitInterval is the default polling interval of LockWait.
+// Use WithWaitInterval or WithBackoff to configure it per object.
 var LockWaitInterval = 1 * time.Second
 
-func (obj *Object) LockWait(ctx context.Context) (*Lock, error) {
+func (obj *Object) LockWait(ctx context.Context, opts ...Option) (*Lock, error) {
+	return obj.lockWait(ctx, obj.options(opts), obj.lock)
+}
+
+type lockFunc func(ctx context.Context, o *options) (*Lock, error)
+
+// lockWait retries lock until it succeeds or ctx is done.
+// obj names the waited resource in the logs and the fair queue.
+func (obj *Object) lockWait(ctx context.Context, o *options, lock lockFunc) (*Lock, error) {
+	if o.fairQueue {
+		return obj.lockWaitFair(ctx, o, lock)
+	}
+
 	// first time
-	lock, err := obj.Lock(ctx)
+	l, err := lock(ctx, o)
 
 	if err == nil {
-		return lock, nil
+		return l, nil
 	}
 
 	if !errors.Is(err, ErrLockAlreadyHeld) {
@@ -216,29 +502,43 @@ func (obj *Object) LockWait(ctx context.Context) (*Lock, error) {
 	}
 
 	// after the second time
-	ticker := time.NewTicker(LockWaitInterval)
-	defer ticker.Stop()
 	lastErr := err
+	var wait time.Duration
+
+	for attempt := 1; ; attempt++ {
+		wait = o.backoff.Next(attempt, wait)
+		o.logger.Debug("waiting for lock", "url", backendURL(obj.backend, obj.key), "attempt", attempt, "wait", wait)
+		timer := time.NewTimer(wait)
 
-L:
-	for {
 		select {
 		case <-ctx.Done():
-			break L
-		case <-ticker.C:
-			lock, err := obj.Lock(ctx)
+			timer.Stop()
+			return nil, lastErr
+		case <-timer.C:
+		}
 
-			if err == nil {
-				return lock, nil
-			}
+		l, err := lock(ctx, o)
 
-			if !errors.Is(err, ErrLockAlreadyHeld) {
-				return nil, err
-			}
+		if err == nil {
+			return l, nil
+		}
 
-			lastErr = err
+		if !errors.Is(err, ErrLockAlreadyHeld) {
+			return nil, err
 		}
+
+		lastErr = err
+	}
+}
+
+// lockError converts a backend error of a conditional operation on the held lock.
+func lockError(err error) error {
+	switch {
+	case errors.Is(err, ErrObjectNotFound):
+		return ErrAlreadyUnlocked
+	case errors.Is(err, ErrPreconditionFailed):
+		return ErrLockMismatch
 	}
 
-	return nil, lastErr
+	return err
 }
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/s3locktest"
 )
 
 func TestLock(t *testing.T) {
@@ -38,6 +41,46 @@ func TestLock(t *testing.T) {
 	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
 }
 
+func TestLockWithTTL(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	// Lock
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Minute))
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Minute), lock.ExpiresAt(), 10*time.Second)
+
+	// Confirm that the lock object has the expiry
+	body, err := testGetObject(t, s3cli, "s3lock-test", "lock-obj")
+	require.NoError(t, err)
+	var lease struct {
+		Id         string
+		AcquiredAt time.Time
+		ExpiresAt  time.Time
+	}
+	err = json.Unmarshal([]byte(body), &lease)
+	require.NoError(t, err)
+	require.Regexp(t, `^\w{8}-\w{4}-\w{4}-\w{4}-\w{12}$`, lease.Id)
+	require.True(t, lease.ExpiresAt.Equal(lock.ExpiresAt()))
+	require.Equal(t, time.Minute, lease.ExpiresAt.Sub(lease.AcquiredAt))
+
+	// The lock file keeps the expiry
+	j, err := json.Marshal(lock)
+	require.NoError(t, err)
+	lock, err = s3lock.NewLockFromJSON(s3cli, j)
+	require.NoError(t, err)
+	require.True(t, lease.ExpiresAt.Equal(lock.ExpiresAt()))
+
+	// Unlock
+	err = lock.Unlock()
+	require.NoError(t, err)
+
+	// Confirm that the lock object does not exist
+	_, err = testGetObject(t, s3cli, "s3lock-test", "lock-obj")
+	require.ErrorContains(t, err, "The specified key does not exist")
+}
+
 func TestLockError(t *testing.T) {
 	s3cli := testNewS3Client(t)
 	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
@@ -61,6 +104,162 @@ func TestLockError(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestLockTakeOver(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(500*time.Millisecond))
+	require.NoError(t, err)
+
+	// Other clients cannot lock it before it expires
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Other clients can take over the expired lock
+	time.Sleep(1 * time.Second)
+	lock2, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	// The expired lock has been lost
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+
+	// A lock without TTL does not expire
+	time.Sleep(1 * time.Second)
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Unlock
+	err = lock2.Unlock()
+	require.NoError(t, err)
+}
+
+func TestLockUnlockedBeforeTakeOver(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj")
+	backend.Put("lock-obj", []byte(`{"Id":"other-id"}`))
+	var once sync.Once
+
+	// Unlocked between the first attempt and reading the lock
+	backend.InjectFailure(func(op s3locktest.Op, key string) error {
+		if op == s3locktest.OpGet {
+			once.Do(func() {
+				backend.Delete(context.Background(), key, "") //nolint:errcheck
+			})
+		}
+
+		return nil
+	})
+
+	lock, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
+
+func TestLockTakeOverLost(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj")
+	backend.Put("lock-obj", []byte(`{"Id":"expired-id","ExpiresAt":"2025-01-01T10:00:00Z"}`))
+	var once sync.Once
+
+	// Another contender takes over the expired lock first
+	backend.InjectFailure(func(op s3locktest.Op, key string) error {
+		if op == s3locktest.OpReplace {
+			once.Do(func() {
+				backend.Put(key, []byte(`{"Id":"winner-id","Holder":{"Hostname":"host-b"}}`))
+			})
+		}
+
+		return nil
+	})
+
+	_, err := obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+	var heldErr *s3lock.LockHeldError
+	require.ErrorAs(t, err, &heldErr)
+	require.Equal(t, "winner-id", heldErr.Id)
+	require.Equal(t, "host-b", heldErr.Holder.Hostname)
+}
+
+func TestRefresh(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(500*time.Millisecond))
+	require.NoError(t, err)
+	expiresAt := lock.ExpiresAt()
+
+	// Extend the lease
+	err = lock.Refresh(t.Context(), time.Minute)
+	require.NoError(t, err)
+	require.True(t, lock.ExpiresAt().After(expiresAt))
+
+	// Other clients cannot take over the refreshed lock
+	time.Sleep(1 * time.Second)
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Unlock with the updated ETag
+	err = lock.Unlock()
+	require.NoError(t, err)
+
+	// Already unlocked
+	err = lock.Refresh(t.Context(), time.Minute)
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+}
+
+func TestRefreshInvalidTTL(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj")
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Minute))
+	require.NoError(t, err)
+	expiresAt := lock.ExpiresAt()
+
+	// The lease cannot be turned into a lock that never expires
+	err = lock.Refresh(t.Context(), 0)
+	require.ErrorContains(t, err, "invalid ttl: 0s")
+	err = lock.Refresh(t.Context(), -time.Second)
+	require.ErrorContains(t, err, "invalid ttl: -1s")
+	info, err := obj.Info(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, expiresAt, info.ExpiresAt)
+
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
+
+func TestRefreshLockLost(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Lock
+	lock, err := obj.Lock(t.Context(), s3lock.WithTTL(500*time.Millisecond))
+	require.NoError(t, err)
+
+	// Other clients take over the expired lock
+	time.Sleep(1 * time.Second)
+	_, err = obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	// The lock has been lost
+	err = lock.Refresh(t.Context(), time.Minute)
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+
+	// The lock has been deleted
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+	err = lock.Refresh(t.Context(), time.Minute)
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+}
+
 func TestLockFatal(t *testing.T) {
 	s3cli := testNewS3Client(t)
 
@@ -186,6 +385,23 @@ func TestLockWait2ndOK(t *testing.T) {
 	<-done
 }
 
+func TestLockWaitTakeOver(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+
+	// Not unlock
+	_, err := obj.Lock(t.Context(), s3lock.WithTTL(1*time.Second))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 3*time.Second)
+	defer cancel()
+	lock, err := obj.LockWait(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+}
+
 func TestLockWaitError(t *testing.T) {
 	s3cli := testNewS3Client(t)
 	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
@@ -242,3 +458,107 @@ func TestAlreadyUnlocked(t *testing.T) {
 	err = lock.Unlock()
 	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
 }
+
+func TestLockWithOwner(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj.token")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj", s3lock.WithFencingToken())
+	outer, err := obj.Lock(t.Context(), s3lock.WithOwner("job-1"))
+	require.NoError(t, err)
+
+	// Reentered by the same owner
+	inner, err := obj.Lock(t.Context(), s3lock.WithOwner("job-1"), s3lock.WithTTL(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, outer.Token(), inner.Token())
+	info, err := obj.Info(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "job-1", info.Owner)
+	require.Equal(t, 2, info.HoldCount)
+	// The lock without TTL keeps no expiry
+	require.True(t, info.ExpiresAt.IsZero())
+
+	// Other owners cannot lock
+	_, err = obj.Lock(t.Context(), s3lock.WithOwner("job-2"))
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// The holds share the lock
+	err = outer.Refresh(t.Context(), time.Minute)
+	require.NoError(t, err)
+	err = inner.Validate(t.Context())
+	require.NoError(t, err)
+
+	// Deleted when all the holds are unlocked
+	err = inner.Unlock()
+	require.NoError(t, err)
+	err = inner.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+	info, err = obj.Info(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 1, info.HoldCount)
+
+	err = outer.Unlock()
+	require.NoError(t, err)
+	_, err = obj.Info(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrNotLocked)
+}
+
+func TestLockWithOwnerRefresh(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj", s3lock.WithOwner("job-1"))
+	outer, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Minute))
+	require.NoError(t, err)
+	inner, err := obj.Lock(t.Context(), s3lock.WithTTL(time.Hour))
+	require.NoError(t, err)
+	info, err := obj.Info(t.Context())
+	require.NoError(t, err)
+	expiresAt := info.ExpiresAt
+
+	// The outer hold cannot shorten the lease of the inner hold
+	err = outer.Refresh(t.Context(), time.Minute)
+	require.NoError(t, err)
+	info, err = obj.Info(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, expiresAt, info.ExpiresAt)
+	require.Equal(t, expiresAt, outer.ExpiresAt())
+
+	// but can extend it
+	err = outer.Refresh(t.Context(), 2*time.Hour)
+	require.NoError(t, err)
+	info, err = obj.Info(t.Context())
+	require.NoError(t, err)
+	require.True(t, info.ExpiresAt.After(expiresAt))
+
+	err = inner.Unlock()
+	require.NoError(t, err)
+	err = outer.Unlock()
+	require.NoError(t, err)
+}
+
+func TestLockWithOwnerConcurrent(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj", s3lock.WithOwner("job-1"))
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			lock, err := obj.Lock(t.Context())
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.NoError(t, lock.Unlock())
+		}()
+	}
+
+	wg.Wait()
+	_, ok := backend.Data("lock-obj")
+	require.False(t, ok)
+}
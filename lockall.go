@@ -0,0 +1,104 @@
+package s3lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// MultiLock is a set of locks acquired together by LockAll/LockAllWait.
+type MultiLock struct {
+	locks []*Lock
+}
+
+// LockAll locks the objects in the canonical order of their URLs,
+// so that holders of overlapping sets of objects never deadlock.
+// If any of them fails, the locks already acquired are released.
+func LockAll(ctx context.Context, objs ...*Object) (*MultiLock, error) {
+	return lockAll(ctx, objs, (*Object).Lock)
+}
+
+// LockAllWait is LockAll with Object.LockWait.
+func LockAllWait(ctx context.Context, objs ...*Object) (*MultiLock, error) {
+	return lockAll(ctx, objs, (*Object).LockWait)
+}
+
+func lockAll(ctx context.Context, objs []*Object, lock func(*Object, context.Context, ...Option) (*Lock, error)) (*MultiLock, error) {
+	objs = slices.Clone(objs)
+	compareURL := func(a, b *Object) int {
+		return strings.Compare(backendURL(a.backend, a.key), backendURL(b.backend, b.key))
+	}
+	slices.SortFunc(objs, compareURL)
+	objs = slices.CompactFunc(objs, func(a, b *Object) bool { return compareURL(a, b) == 0 })
+	ml := &MultiLock{}
+
+	for _, obj := range objs {
+		l, err := lock(obj, ctx)
+
+		if err != nil {
+			ml.UnlockContext(context.WithoutCancel(ctx)) //nolint:errcheck
+			return nil, err
+		}
+
+		ml.locks = append(ml.locks, l)
+	}
+
+	return ml, nil
+}
+
+// Locks returns the locks in the order of acquisition.
+func (ml *MultiLock) Locks() []*Lock {
+	return slices.Clone(ml.locks)
+}
+
+func (ml *MultiLock) Unlock() error {
+	return ml.UnlockContext(context.Background())
+}
+
+// UnlockContext releases all the locks in the reverse order of acquisition,
+// even if some of them fail.
+func (ml *MultiLock) UnlockContext(ctx context.Context) error {
+	var errs []error
+
+	for _, l := range slices.Backward(ml.locks) {
+		errs = append(errs, l.UnlockContext(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+// MarshalJSON returns the JSON array of the locks.
+func (ml *MultiLock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ml.locks)
+}
+
+func NewMultiLockFromJSON(s3Client S3API, data []byte) (*MultiLock, error) {
+	return NewMultiLockFromJSONWithResolver(data, func(_ string, bucket string) (Backend, error) {
+		return NewS3Backend(s3Client, bucket), nil
+	})
+}
+
+func NewMultiLockFromJSONWithResolver(data []byte, resolve BackendResolver) (*MultiLock, error) {
+	var raws []json.RawMessage
+	err := json.Unmarshal(data, &raws)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ml := &MultiLock{}
+
+	for _, raw := range raws {
+		l, err := NewLockFromJSONWithResolver(raw, resolve)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ml.locks = append(ml.locks, l)
+	}
+
+	return ml, nil
+}
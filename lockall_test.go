@@ -0,0 +1,60 @@
+package s3lock_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/s3locktest"
+)
+
+func TestLockAll(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	objA := s3lock.NewWithBackend(backend, "lock-a")
+	objB := s3lock.NewWithBackend(backend, "lock-b")
+
+	// Locked in the sorted order
+	ml, err := s3lock.LockAll(t.Context(), objB, objA, objB)
+	require.NoError(t, err)
+	locks := ml.Locks()
+	require.Len(t, locks, 2)
+	require.Equal(t, "lock-a", locks[0].Key())
+	require.Equal(t, "lock-b", locks[1].Key())
+
+	// JSON round trip
+	j, err := ml.MarshalJSON()
+	require.NoError(t, err)
+	ml2, err := s3lock.NewMultiLockFromJSONWithResolver(j, func(_ string, _ string) (s3lock.Backend, error) {
+		return backend, nil
+	})
+	require.NoError(t, err)
+
+	err = ml2.Unlock()
+	require.NoError(t, err)
+	_, ok := backend.Data("lock-a")
+	require.False(t, ok)
+	_, ok = backend.Data("lock-b")
+	require.False(t, ok)
+
+	// Already unlocked
+	err = ml.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrAlreadyUnlocked)
+}
+
+func TestLockAllRollback(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	objA := s3lock.NewWithBackend(backend, "lock-a")
+	objB := s3lock.NewWithBackend(backend, "lock-b")
+	lockB, err := objB.Lock(t.Context())
+	require.NoError(t, err)
+
+	_, err = s3lock.LockAll(t.Context(), objA, objB)
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// lock-a is released
+	_, ok := backend.Data("lock-a")
+	require.False(t, ok)
+
+	err = lockB.Unlock()
+	require.NoError(t, err)
+}
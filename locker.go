@@ -0,0 +1,91 @@
+package s3lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Locker adapts an Object to sync.Locker for code that expects a mutex.
+type Locker struct {
+	obj  *Object
+	ctx  context.Context
+	opts []Option
+	// serializes the holders in this process so that they do not poll the backend
+	local sync.Mutex
+	lock  *Lock
+}
+
+var _ sync.Locker = (*Locker)(nil)
+
+// NewLocker returns a Locker that locks the object with ctx and the options.
+// The wait is bounded by the deadline of ctx and configured by the options, e.g., WithBackoff.
+func NewLocker(ctx context.Context, obj *Object, opts ...Option) *Locker {
+	return &Locker{
+		obj:  obj,
+		ctx:  ctx,
+		opts: opts,
+	}
+}
+
+// Lock blocks with Object.LockWait until the lock is acquired.
+// It panics if the lock cannot be acquired, e.g., when ctx is done,
+// since sync.Locker cannot return an error. Use Object directly to handle the errors.
+func (l *Locker) Lock() {
+	l.local.Lock()
+	lock, err := l.obj.LockWait(l.ctx, l.opts...)
+
+	if err != nil {
+		l.local.Unlock()
+		panic(fmt.Errorf("s3lock: failed to lock %s: %w", backendURL(l.obj.backend, l.obj.key), err))
+	}
+
+	l.lock = lock
+}
+
+// TryLock tries to acquire the lock without waiting and reports whether it succeeded.
+func (l *Locker) TryLock() bool {
+	if !l.local.TryLock() {
+		return false
+	}
+
+	lock, err := l.obj.Lock(l.ctx, l.opts...)
+
+	if err != nil {
+		if !errors.Is(err, ErrLockAlreadyHeld) {
+			l.obj.options(l.opts).logger.Error("failed to lock", "url", backendURL(l.obj.backend, l.obj.key), "error", err)
+		}
+
+		l.local.Unlock()
+		return false
+	}
+
+	l.lock = lock
+
+	return true
+}
+
+// Unlock releases the lock.
+// Like sync.Mutex, it panics if the Locker is not locked,
+// or if the lock has been lost, i.e., ErrLockMismatch or ErrAlreadyUnlocked.
+// Other errors are logged with the logger of the options, since the lock will expire.
+func (l *Locker) Unlock() {
+	lock := l.lock
+
+	if lock == nil {
+		panic("s3lock: unlock of unlocked Locker")
+	}
+
+	l.lock = nil
+	err := lock.UnlockContext(context.WithoutCancel(l.ctx))
+	l.local.Unlock()
+
+	if errors.Is(err, ErrLockMismatch) || errors.Is(err, ErrAlreadyUnlocked) {
+		panic(fmt.Errorf("s3lock: failed to unlock %s: %w", lock, err))
+	}
+
+	if err != nil {
+		l.obj.options(l.opts).logger.Error("failed to unlock", "url", lock.String(), "error", err)
+	}
+}
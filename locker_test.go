@@ -0,0 +1,117 @@
+package s3lock_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/s3locktest"
+)
+
+func TestLocker(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj", s3lock.WithWaitInterval(time.Millisecond))
+	count := 0
+	var wg sync.WaitGroup
+
+	// Lockers of different processes
+	for range 3 {
+		var locker sync.Locker = s3lock.NewLocker(t.Context(), obj)
+
+		for range 2 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for range 5 {
+					locker.Lock()
+					c := count
+					time.Sleep(time.Millisecond)
+					count = c + 1
+					locker.Unlock()
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+	require.Equal(t, 30, count)
+	_, ok := backend.Data("lock-obj")
+	require.False(t, ok)
+}
+
+func TestLockerTryLock(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj")
+	locker1 := s3lock.NewLocker(t.Context(), obj)
+	locker2 := s3lock.NewLocker(t.Context(), obj)
+
+	require.True(t, locker1.TryLock())
+	require.False(t, locker1.TryLock())
+	require.False(t, locker2.TryLock())
+	locker1.Unlock()
+	require.True(t, locker2.TryLock())
+	locker2.Unlock()
+}
+
+func TestLockerPanic(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj")
+	locker := s3lock.NewLocker(t.Context(), obj)
+
+	require.PanicsWithValue(t, "s3lock: unlock of unlocked Locker", locker.Unlock)
+
+	// Cannot be acquired before ctx is done
+	_, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+		}()
+
+		s3lock.NewLocker(ctx, obj).Lock()
+	}()
+
+	// The lock is lost
+	backend.Delete(t.Context(), "lock-obj", "") //nolint:errcheck
+	locker.Lock()
+	backend.Put("lock-obj", []byte(`{"Id":"other-id"}`))
+
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+		}()
+
+		locker.Unlock()
+	}()
+
+	// The Locker can be locked again after the panic
+	backend.Delete(t.Context(), "lock-obj", "") //nolint:errcheck
+	require.True(t, locker.TryLock())
+	locker.Unlock()
+}
+
+func TestLockerUnlockError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj")
+	locker := s3lock.NewLocker(t.Context(), obj, s3lock.WithLogger(logger))
+
+	// The lock will expire
+	locker.Lock()
+	backend.FailNext(s3locktest.OpDelete, s3locktest.ErrInjected)
+	require.NotPanics(t, locker.Unlock)
+	require.Contains(t, buf.String(), `msg="failed to unlock" url=mem://s3lock-test/lock-obj`)
+}
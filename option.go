@@ -0,0 +1,143 @@
+package s3lock
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+)
+
+type options struct {
+	ttl         time.Duration
+	fencing     bool
+	holder      *Holder
+	backoff     Backoff
+	idGenerator func() string
+	logger      *slog.Logger
+	sse         types.ServerSideEncryption
+	sseKMSKeyID string
+	metadata    map[string]string
+	fairQueue   bool
+	ticketTTL   time.Duration
+	owner       string
+}
+
+// Option configures an Object when passed to New/NewWithBackend,
+// or a single acquisition when passed to Lock/LockWait, overriding the Object's options.
+// WithSSE and WithMetadata configure the S3 backend created by New, NewRW and NewSemaphore,
+// so they take effect only when the Object is built and are ignored elsewhere.
+type Option func(*options)
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		idGenerator: uuid.NewString,
+		logger:      slog.New(slog.DiscardHandler),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.backoff == nil {
+		// read at acquisition so that changes to the global take effect
+		o.backoff = FixedBackoff(LockWaitInterval)
+	}
+
+	return o
+}
+
+func (obj *Object) options(opts []Option) *options {
+	return newOptions(append(slices.Clone(obj.opts), opts...))
+}
+
+// WithTTL sets the lease duration of the lock.
+// A lock without TTL never expires.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithFencingToken issues a monotonic fencing token with every acquisition.
+// The counter is kept in the companion object "<key>.token".
+func WithFencingToken() Option {
+	return func(o *options) {
+		o.fencing = true
+	}
+}
+
+// WithHolder records the holder information in the lock object.
+func WithHolder(h *Holder) Option {
+	return func(o *options) {
+		o.holder = h
+	}
+}
+
+// WithWaitInterval sets the polling interval of LockWait (default: LockWaitInterval).
+func WithWaitInterval(interval time.Duration) Option {
+	return WithBackoff(FixedBackoff(interval))
+}
+
+// WithBackoff sets the retry strategy of LockWait.
+func WithBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+	}
+}
+
+// WithIDGenerator sets the generator of lock ids (default: UUID v4).
+// The ids must be unique across all holders of the key.
+func WithIDGenerator(fn func() string) Option {
+	return func(o *options) {
+		o.idGenerator = fn
+	}
+}
+
+// WithLogger logs retries, takeovers and lease renewals.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithSSE sets the server-side encryption of the lock objects written by the S3 backend of New.
+// It is ignored by NewWithBackend and Lock/LockWait.
+func WithSSE(sse types.ServerSideEncryption, kmsKeyID string) Option {
+	return func(o *options) {
+		o.sse = sse
+		o.sseKMSKeyID = kmsKeyID
+	}
+}
+
+// WithMetadata sets the user-defined metadata of the lock objects written by the S3 backend of New.
+// It is ignored by NewWithBackend and Lock/LockWait.
+func WithMetadata(metadata map[string]string) Option {
+	return func(o *options) {
+		o.metadata = metadata
+	}
+}
+
+// WithOwner makes the lock reentrant for the owner.
+// Lock by the owner that already holds the lock succeeds and increments the hold count,
+// and the lock object is deleted when all the holds are unlocked.
+func WithOwner(owner string) Option {
+	return func(o *options) {
+		o.owner = owner
+	}
+}
+
+// WithFairQueue makes LockWait wait in a FIFO queue of tickets under "<key>.queue/",
+// so that the waiters acquire the lock in arrival order.
+// A ticket of a crashed waiter expires ticketTTL after its next poll was due (default: DefaultTicketTTL).
+// Lock and LockWait without this option do not take part in the queue.
+// The backend must support List; with New, the S3 client must implement S3ListAPI,
+// or LockWait fails.
+func WithFairQueue(ticketTTL time.Duration) Option {
+	return func(o *options) {
+		o.fairQueue = true
+		o.ticketTTL = cmp.Or(ticketTTL, DefaultTicketTTL)
+	}
+}
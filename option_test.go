@@ -0,0 +1,127 @@
+package s3lock_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/s3locktest"
+)
+
+func TestObjectOptions(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj", s3lock.WithTTL(time.Minute), s3lock.WithIDGenerator(func() string { return "my-id" }))
+
+	// Object defaults
+	lock, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Minute), lock.ExpiresAt(), 10*time.Second)
+
+	info, err := obj.Info(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "my-id", info.Id)
+
+	err = lock.Unlock()
+	require.NoError(t, err)
+
+	// Override per call
+	lock, err = obj.Lock(t.Context(), s3lock.WithTTL(time.Hour))
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), lock.ExpiresAt(), 10*time.Second)
+
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
+
+func TestWithWaitInterval(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj", s3lock.WithWaitInterval(10*time.Millisecond))
+	_, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	var creates atomic.Int32
+
+	backend.InjectFailure(func(op s3locktest.Op, _ string) error {
+		if op == s3locktest.OpCreate {
+			creates.Add(1)
+		}
+
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 500*time.Millisecond)
+	defer cancel()
+	_, err = obj.LockWait(ctx)
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+	// The global LockWaitInterval (100ms in tests) allows only 5 attempts
+	require.Greater(t, creates.Load(), int32(10))
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj", s3lock.WithLogger(logger))
+	_, err := obj.Lock(t.Context(), s3lock.WithTTL(100*time.Millisecond))
+	require.NoError(t, err)
+
+	// Wait and take over
+	_, err = obj.LockWait(t.Context())
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `msg="waiting for lock" url=mem://s3lock-test/lock-obj attempt=1`)
+	require.Contains(t, buf.String(), `msg="took over expired lock" url=mem://s3lock-test/lock-obj`)
+}
+
+type testPutRecorder struct {
+	s3lock.S3API
+	inputs []*s3.PutObjectInput
+}
+
+func (r *testPutRecorder) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	r.inputs = append(r.inputs, params)
+	return r.S3API.PutObject(ctx, params, optFns...)
+}
+
+func TestWithSSEAndMetadata(t *testing.T) {
+	s3cli := &testPutRecorder{S3API: &testS3API{objects: map[string]*testS3Object{}}}
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj",
+		s3lock.WithSSE(types.ServerSideEncryptionAwsKms, "my-key"),
+		s3lock.WithMetadata(map[string]string{"owner": "ci"}),
+	)
+
+	lock, err := obj.Lock(t.Context(), s3lock.WithFencingToken())
+	require.NoError(t, err)
+
+	// The lock object and the token counter
+	require.Len(t, s3cli.inputs, 2)
+
+	for _, input := range s3cli.inputs {
+		require.Equal(t, types.ServerSideEncryptionAwsKms, input.ServerSideEncryption)
+		require.Equal(t, "my-key", aws.ToString(input.SSEKMSKeyId))
+		require.Equal(t, map[string]string{"owner": "ci"}, input.Metadata)
+	}
+
+	err = lock.Unlock()
+	require.NoError(t, err)
+
+	// Ignored by a single acquisition
+	s3cli.inputs = nil
+	obj = s3lock.New(s3cli, "s3lock-test", "lock-obj")
+	lock, err = obj.Lock(t.Context(), s3lock.WithSSE(types.ServerSideEncryptionAwsKms, "my-key"), s3lock.WithMetadata(map[string]string{"owner": "ci"}))
+	require.NoError(t, err)
+	require.Len(t, s3cli.inputs, 1)
+	require.Empty(t, s3cli.inputs[0].ServerSideEncryption)
+	require.Nil(t, s3cli.inputs[0].Metadata)
+
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
@@ -0,0 +1,193 @@
+package s3lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	queueKeySuffix   = ".queue/"
+	queueCounterName = "counter"
+	queueTicketName  = "ticket-"
+)
+
+// DefaultTicketTTL is the lifetime of a queue ticket used when WithFairQueue is given zero.
+var DefaultTicketTTL = 1 * time.Minute
+
+type ticketBody struct {
+	Id        string
+	ExpiresAt time.Time
+}
+
+type ticket struct {
+	key  string
+	id   string
+	etag string
+}
+
+// lockWaitFair waits in the FIFO queue "<key>.queue/".
+// Each waiter registers a numbered ticket and only the waiter with the lowest live ticket attempts the lock.
+// Tickets are renewed before every wait and expire the ticket TTL after the next poll is due,
+// so a crashed waiter does not block the queue.
+func (obj *Object) lockWaitFair(ctx context.Context, o *options, lock lockFunc) (*Lock, error) {
+	t, err := obj.enqueue(ctx, o, o.ticketTTL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		// leave the queue even if ctx is done
+		obj.backend.Delete(context.WithoutCancel(ctx), t.key, t.etag) //nolint:errcheck
+	}()
+
+	var lastErr error = ErrLockAlreadyHeld
+	var wait time.Duration
+
+	for attempt := 1; ; attempt++ {
+		head, err := obj.queueHead(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if head == t.key {
+			l, err := lock(ctx, o)
+
+			if err == nil {
+				return l, nil
+			}
+
+			if !errors.Is(err, ErrLockAlreadyHeld) {
+				return nil, err
+			}
+
+			lastErr = err
+		}
+
+		wait = o.backoff.Next(attempt, wait)
+
+		// keep the ticket alive until the next poll
+		err = obj.renewTicket(ctx, t, wait+o.ticketTTL)
+
+		if errors.Is(err, ErrObjectNotFound) || errors.Is(err, ErrPreconditionFailed) {
+			// removed by another waiter after expiry
+			o.logger.Warn("queue ticket expired, re-registering", "url", backendURL(obj.backend, obj.key), "ticket", t.key)
+			var newTicket *ticket
+			newTicket, err = obj.enqueue(ctx, o, wait+o.ticketTTL)
+
+			// keep the old ticket for the cleanup if it fails
+			if err == nil {
+				t = newTicket
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		o.logger.Debug("waiting in queue", "url", backendURL(obj.backend, obj.key), "ticket", t.key, "head", head, "attempt", attempt, "wait", wait)
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, lastErr
+		case <-timer.C:
+		}
+	}
+}
+
+func (obj *Object) enqueue(ctx context.Context, o *options, ttl time.Duration) (*ticket, error) {
+	n, err := incrementCounter(ctx, obj.backend, obj.key+queueKeySuffix+queueCounterName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := &ticket{
+		// zero-padded so that the keys are listed in order
+		key: fmt.Sprintf("%s%s%s%020d", obj.key, queueKeySuffix, queueTicketName, n),
+		id:  o.idGenerator(),
+	}
+
+	data, err := json.Marshal(&ticketBody{Id: t.id, ExpiresAt: time.Now().UTC().Add(ttl)})
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.etag, err = obj.backend.Create(ctx, t.key, data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (obj *Object) renewTicket(ctx context.Context, t *ticket, ttl time.Duration) error {
+	data, err := json.Marshal(&ticketBody{Id: t.id, ExpiresAt: time.Now().UTC().Add(ttl)})
+
+	if err != nil {
+		return err
+	}
+
+	etag, err := obj.backend.Replace(ctx, t.key, data, t.etag)
+
+	if err != nil {
+		return err
+	}
+
+	t.etag = etag
+
+	return nil
+}
+
+// queueHead returns the key of the lowest live ticket, removing expired tickets.
+func (obj *Object) queueHead(ctx context.Context) (string, error) {
+	keys, err := obj.backend.List(ctx, obj.key+queueKeySuffix+queueTicketName)
+
+	if err != nil {
+		return "", err
+	}
+
+	slices.Sort(keys)
+	now := time.Now()
+
+	for _, key := range keys {
+		entry, err := obj.backend.Get(ctx, key, "")
+
+		if err != nil {
+			if errors.Is(err, ErrObjectNotFound) {
+				continue
+			}
+
+			return "", err
+		}
+
+		body := &ticketBody{}
+
+		if json.Unmarshal(entry.Data, body) == nil && now.Before(body.ExpiresAt) {
+			return key, nil
+		}
+
+		// remove the abandoned ticket only if it has not been renewed since it was read
+		err = obj.backend.Delete(ctx, key, entry.ETag)
+
+		if err != nil && !errors.Is(err, ErrObjectNotFound) && !errors.Is(err, ErrPreconditionFailed) {
+			return "", err
+		}
+
+		if errors.Is(err, ErrPreconditionFailed) {
+			// renewed by its waiter
+			return key, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,132 @@
+package s3lock_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/s3locktest"
+)
+
+func TestLockWaitFairQueue(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj", s3lock.WithWaitInterval(10*time.Millisecond), s3lock.WithFairQueue(0))
+	lock, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+
+	for i := range 3 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			l, err := obj.LockWait(t.Context())
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			assert.NoError(t, l.Unlock())
+		}()
+
+		// register the tickets in order
+		require.Eventually(t, func() bool {
+			keys, _ := backend.List(t.Context(), "lock-obj.queue/ticket-")
+			return len(keys) == i+1
+		}, time.Second, time.Millisecond)
+	}
+
+	err = lock.Unlock()
+	require.NoError(t, err)
+	wg.Wait()
+	require.Equal(t, []int{0, 1, 2}, order)
+
+	// The tickets are removed
+	keys, err := backend.List(t.Context(), "lock-obj.queue/ticket-")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	// Companion objects are not listed
+	infos, err := s3lock.ListWithBackend(t.Context(), backend, "")
+	require.NoError(t, err)
+	require.Empty(t, infos)
+}
+
+func TestLockWaitFairQueueAbandonedTicket(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj", s3lock.WithWaitInterval(10*time.Millisecond))
+
+	// Ticket of a crashed waiter
+	expired, _ := json.Marshal(map[string]any{"Id": "crashed", "ExpiresAt": time.Now().Add(-time.Second)})
+	backend.Put("lock-obj.queue/ticket-00000000000000000000", expired)
+
+	lock, err := obj.LockWait(t.Context(), s3lock.WithFairQueue(0))
+	require.NoError(t, err)
+	_, ok := backend.Data("lock-obj.queue/ticket-00000000000000000000")
+	require.False(t, ok)
+
+	err = lock.Unlock()
+	require.NoError(t, err)
+
+	// Ticket of a live waiter ahead
+	live, _ := json.Marshal(map[string]any{"Id": "live", "ExpiresAt": time.Now().Add(time.Hour)})
+	backend.Put("lock-obj.queue/ticket-00000000000000000000", live)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	_, err = obj.LockWait(ctx, s3lock.WithFairQueue(0))
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// The lock is not attempted out of turn
+	_, ok = backend.Data("lock-obj")
+	require.False(t, ok)
+
+	// Only the live ticket is left
+	keys, err := backend.List(t.Context(), "lock-obj.queue/ticket-")
+	require.NoError(t, err)
+	require.Equal(t, []string{"lock-obj.queue/ticket-00000000000000000000"}, keys)
+}
+
+func TestLockWaitFairQueueReenqueueFailure(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	obj := s3lock.NewWithBackend(backend, "lock-obj", s3lock.WithWaitInterval(10*time.Millisecond), s3lock.WithFairQueue(0))
+	backend.Put("lock-obj", []byte(`{"Id":"other-id"}`))
+	var mu sync.Mutex
+	expired := false
+
+	// The ticket is lost and it cannot be registered again
+	backend.InjectFailure(func(op s3locktest.Op, key string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case op == s3locktest.OpReplace && strings.HasPrefix(key, "lock-obj.queue/ticket-"):
+			expired = true
+			return s3lock.ErrPreconditionFailed
+		case op == s3locktest.OpGet && key == "lock-obj.queue/counter" && expired:
+			return s3locktest.ErrInjected
+		}
+
+		return nil
+	})
+
+	_, err := obj.LockWait(t.Context())
+	require.ErrorIs(t, err, s3locktest.ErrInjected)
+
+	// The first ticket is removed
+	_, ok := backend.Data("lock-obj.queue/ticket-00000000000000000001")
+	require.False(t, ok)
+}
@@ -0,0 +1,190 @@
+package s3lock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const readersKeySuffix = ".readers/"
+
+// RWObject is a shared/exclusive lock.
+// Readers create their own objects under "<key>.readers/",
+// and a writer takes the exclusive key "<key>" and then waits for the readers to drain.
+// While a writer holds or waits for the key, new readers are refused, so writers are not starved.
+type RWObject struct {
+	obj *Object
+}
+
+// NewRW returns the shared/exclusive lock of the key in the bucket.
+// The client must be able to list the readers.
+func NewRW(s3Client S3ListAPI, bucket string, key string, opts ...Option) *RWObject {
+	return NewRWWithBackend(newS3BackendWithOptions(s3Client, bucket, opts), key, opts...)
+}
+
+// NewRWWithBackend returns the shared/exclusive lock of the key.
+// The options are the defaults of Lock/LockWait/RLock/RLockWait.
+func NewRWWithBackend(backend Backend, key string, opts ...Option) *RWObject {
+	return &RWObject{obj: NewWithBackend(backend, key, opts...)}
+}
+
+// Lock takes the exclusive lock.
+// It fails with a LockHeldError of a reader if any reader holds the shared lock.
+func (rw *RWObject) Lock(ctx context.Context, opts ...Option) (*Lock, error) {
+	o := rw.obj.options(opts)
+	lock, err := rw.obj.lock(ctx, o)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := rw.liveReader(ctx)
+
+	if err == nil && reader != nil {
+		err = newLockHeldError(reader)
+	}
+
+	if err != nil {
+		lock.UnlockContext(context.WithoutCancel(ctx)) //nolint:errcheck
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// LockWait takes the exclusive key like Object.LockWait, and then waits for the readers to drain.
+func (rw *RWObject) LockWait(ctx context.Context, opts ...Option) (*Lock, error) {
+	o := rw.obj.options(opts)
+	lock, err := rw.obj.lockWait(ctx, o, rw.obj.lock)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = rw.waitReaders(ctx, o, lock)
+
+	if err != nil {
+		lock.UnlockContext(context.WithoutCancel(ctx)) //nolint:errcheck
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+func (rw *RWObject) waitReaders(ctx context.Context, o *options, lock *Lock) error {
+	var wait time.Duration
+
+	for attempt := 1; ; attempt++ {
+		reader, err := rw.liveReader(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if reader == nil {
+			return nil
+		}
+
+		wait = o.backoff.Next(attempt, wait)
+		o.logger.Debug("waiting for readers", "url", backendURL(rw.obj.backend, rw.obj.key), "reader", reader.Id, "attempt", attempt, "wait", wait)
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return newLockHeldError(reader)
+		case <-timer.C:
+		}
+
+		if o.ttl > 0 {
+			// keep the key while draining
+			err = lock.Refresh(ctx, o.ttl)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// liveReader returns one of the readers holding the shared lock, removing expired readers.
+func (rw *RWObject) liveReader(ctx context.Context) (*LockInfo, error) {
+	readers, err := ListWithBackend(ctx, rw.obj.backend, rw.obj.key+readersKeySuffix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reader := range readers {
+		if !reader.Expired() {
+			return reader, nil
+		}
+
+		err = NewWithBackend(rw.obj.backend, reader.Key).ForceUnlock(ctx, reader.ETag)
+
+		if err != nil && !errors.Is(err, ErrNotLocked) && !errors.Is(err, ErrLockMismatch) {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// RLock takes the shared lock.
+// It fails with a LockHeldError of the writer if a writer holds the exclusive key.
+// WithFencingToken is ignored for readers.
+func (rw *RWObject) RLock(ctx context.Context, opts ...Option) (*Lock, error) {
+	return rw.rlock(ctx, rw.obj.options(opts))
+}
+
+// RLockWait waits for the shared lock like Object.LockWait.
+func (rw *RWObject) RLockWait(ctx context.Context, opts ...Option) (*Lock, error) {
+	return rw.obj.lockWait(ctx, rw.obj.options(opts), rw.rlock)
+}
+
+func (rw *RWObject) rlock(ctx context.Context, o *options) (*Lock, error) {
+	err := rw.checkWriter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the id of the lock is also the name of the reader object
+	id := o.idGenerator()
+	ro := *o
+	ro.idGenerator = func() string { return id }
+	ro.fencing = false
+	lock, err := NewWithBackend(rw.obj.backend, rw.obj.key+readersKeySuffix+id).lock(ctx, &ro)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// A writer that took the key before the reader object was created may not have seen it
+	err = rw.checkWriter(ctx)
+
+	if err != nil {
+		lock.UnlockContext(context.WithoutCancel(ctx)) //nolint:errcheck
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+func (rw *RWObject) checkWriter(ctx context.Context) error {
+	writer, err := rw.obj.Info(ctx)
+
+	if err != nil {
+		if errors.Is(err, ErrNotLocked) {
+			return nil
+		}
+
+		return err
+	}
+
+	if !writer.Expired() {
+		return newLockHeldError(writer)
+	}
+
+	return nil
+}
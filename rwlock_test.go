@@ -0,0 +1,101 @@
+package s3lock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/s3locktest"
+)
+
+func TestRWObject(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	rw := s3lock.NewRWWithBackend(backend, "lock-obj", s3lock.WithWaitInterval(10*time.Millisecond))
+
+	// Readers share the lock
+	r1, err := rw.RLock(t.Context())
+	require.NoError(t, err)
+	r2, err := rw.RLock(t.Context())
+	require.NoError(t, err)
+
+	// A writer is refused while readers hold the lock
+	_, err = rw.Lock(t.Context())
+	var heldErr *s3lock.LockHeldError
+	require.ErrorAs(t, err, &heldErr)
+	require.Contains(t, []string{r1.Key(), r2.Key()}, "lock-obj.readers/"+heldErr.Id)
+	_, ok := backend.Data("lock-obj")
+	require.False(t, ok)
+
+	// A waiting writer refuses new readers
+	done := make(chan *s3lock.Lock)
+
+	go func() {
+		w, _ := rw.LockWait(t.Context())
+		done <- w
+	}()
+
+	require.Eventually(t, func() bool {
+		_, ok := backend.Data("lock-obj")
+		return ok
+	}, time.Second, time.Millisecond)
+
+	_, err = rw.RLock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// The writer gets the lock after the readers drain
+	err = r1.Unlock()
+	require.NoError(t, err)
+	err = r2.Unlock()
+	require.NoError(t, err)
+	w := <-done
+	require.NotNil(t, w)
+
+	_, err = rw.RLock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	err = w.Unlock()
+	require.NoError(t, err)
+	r3, err := rw.RLock(t.Context())
+	require.NoError(t, err)
+	err = r3.Unlock()
+	require.NoError(t, err)
+}
+
+func TestRWObjectExpiredReader(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	rw := s3lock.NewRWWithBackend(backend, "lock-obj")
+
+	// Reader that has crashed
+	r, err := rw.RLock(t.Context(), s3lock.WithTTL(100*time.Millisecond))
+	require.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	w, err := rw.Lock(t.Context())
+	require.NoError(t, err)
+	_, ok := backend.Data(r.Key())
+	require.False(t, ok)
+
+	err = w.Unlock()
+	require.NoError(t, err)
+}
+
+func TestRWObjectS3(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+	rw := s3lock.NewRW(s3cli, "s3lock-test", "lock-obj")
+
+	// The writer lists the readers
+	r, err := rw.RLock(t.Context())
+	require.NoError(t, err)
+	_, err = rw.Lock(t.Context())
+	var heldErr *s3lock.LockHeldError
+	require.ErrorAs(t, err, &heldErr)
+
+	err = r.Unlock()
+	require.NoError(t, err)
+	w, err := rw.Lock(t.Context())
+	require.NoError(t, err)
+	err = w.Unlock()
+	require.NoError(t, err)
+}
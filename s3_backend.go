@@ -0,0 +1,174 @@
+package s3lock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Backend stores lock objects in an S3 bucket using conditional writes.
+type S3Backend struct {
+	s3          S3API
+	bucket      string
+	sse         types.ServerSideEncryption
+	sseKMSKeyID string
+	metadata    map[string]string
+}
+
+func NewS3Backend(s3Client S3API, bucket string) *S3Backend {
+	return &S3Backend{
+		s3:     s3Client,
+		bucket: bucket,
+	}
+}
+
+func (b *S3Backend) Scheme() string {
+	return "s3"
+}
+
+func (b *S3Backend) Bucket() string {
+	return b.bucket
+}
+
+func (b *S3Backend) Create(ctx context.Context, key string, data []byte) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(b.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		IfNoneMatch: aws.String("*"),
+	}
+
+	return b.put(ctx, input)
+}
+
+func (b *S3Backend) Get(ctx context.Context, key string, ifMatch string) (*Entry, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	}
+
+	if ifMatch != "" {
+		input.IfMatch = aws.String(ifMatch)
+	}
+
+	output, err := b.s3.GetObject(ctx, input)
+
+	if err != nil {
+		return nil, s3Error(err)
+	}
+
+	defer output.Body.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(output.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		Data:         data,
+		ETag:         aws.ToString(output.ETag),
+		LastModified: aws.ToTime(output.LastModified),
+	}
+
+	return entry, nil
+}
+
+func (b *S3Backend) Replace(ctx context.Context, key string, data []byte, ifMatch string) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket:  aws.String(b.bucket),
+		Key:     aws.String(key),
+		Body:    bytes.NewReader(data),
+		IfMatch: aws.String(ifMatch),
+	}
+
+	return b.put(ctx, input)
+}
+
+func (b *S3Backend) Delete(ctx context.Context, key string, ifMatch string) error {
+	input := &s3.DeleteObjectInput{
+		Bucket:  aws.String(b.bucket),
+		Key:     aws.String(key),
+		IfMatch: aws.String(ifMatch),
+	}
+
+	_, err := b.s3.DeleteObject(ctx, input)
+
+	return s3Error(err)
+}
+
+// List requires the S3 client to implement S3ListAPI.
+func (b *S3Backend) List(ctx context.Context, prefix string) ([]string, error) {
+	s3Client, ok := b.s3.(S3ListAPI)
+
+	if !ok {
+		return nil, errors.New("S3 client does not support ListObjectsV2")
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(prefix),
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(s3Client, input)
+	keys := []string{}
+
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range output.Contents {
+			keys = append(keys, aws.ToString(content.Key))
+		}
+	}
+
+	return keys, nil
+}
+
+func (b *S3Backend) put(ctx context.Context, input *s3.PutObjectInput) (string, error) {
+	input.ServerSideEncryption = b.sse
+	input.Metadata = b.metadata
+
+	if b.sseKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(b.sseKMSKeyID)
+	}
+
+	output, err := b.s3.PutObject(ctx, input)
+
+	if err != nil {
+		return "", s3Error(err)
+	}
+
+	return aws.ToString(output.ETag), nil
+}
+
+func s3Error(err error) error {
+	switch httpStatusCode(err) {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrObjectNotFound, err)
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("%w: %w", ErrPreconditionFailed, err)
+	}
+
+	return err
+}
+
+func httpStatusCode(err error) int {
+	var respErr interface{ HTTPStatusCode() int }
+
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode()
+	}
+
+	return 0
+}
@@ -0,0 +1,255 @@
+// Package s3locktest provides an in-memory s3lock backend for unit tests.
+package s3locktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/winebarrel/s3lock"
+)
+
+var ErrInjected = errors.New("injected failure")
+
+type Op string
+
+const (
+	OpCreate  Op = "Create"
+	OpGet     Op = "Get"
+	OpReplace Op = "Replace"
+	OpDelete  Op = "Delete"
+	OpList    Op = "List"
+)
+
+type object struct {
+	data         []byte
+	etag         string
+	lastModified time.Time
+}
+
+// Backend is an in-memory s3lock.Backend that honours the conditional operations.
+// It is safe for concurrent use.
+type Backend struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string]*object
+	seq     uint64
+	latency time.Duration
+	failure func(op Op, key string) error
+}
+
+var _ s3lock.Backend = (*Backend)(nil)
+
+func NewBackend(bucket string) *Backend {
+	return &Backend{
+		bucket:  bucket,
+		objects: map[string]*object{},
+	}
+}
+
+// NewObject returns the lock object of the key on a new backend.
+func NewObject(key string) (*s3lock.Object, *Backend) {
+	b := NewBackend("s3locktest")
+	return s3lock.NewWithBackend(b, key), b
+}
+
+func (b *Backend) Scheme() string {
+	return "mem"
+}
+
+func (b *Backend) Bucket() string {
+	return b.bucket
+}
+
+// SetLatency delays every operation. The delay is interrupted by the context.
+func (b *Backend) SetLatency(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latency = d
+}
+
+// InjectFailure makes the operation fail with the error returned by fn.
+// Operations for which fn returns nil succeed. Pass nil to stop the injection.
+func (b *Backend) InjectFailure(fn func(op Op, key string) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failure = fn
+}
+
+// FailNext makes the next operation of op fail with err.
+func (b *Backend) FailNext(op Op, err error) {
+	var once sync.Once
+
+	b.InjectFailure(func(o Op, _ string) error {
+		var injected error
+
+		if o == op {
+			once.Do(func() { injected = err })
+		}
+
+		return injected
+	})
+}
+
+// Put stores the object unconditionally, e.g., to simulate a lock held by another process.
+func (b *Backend) Put(key string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.put(key, data)
+}
+
+// Data returns the content of the object.
+func (b *Backend) Data(key string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	obj, ok := b.objects[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	return slices.Clone(obj.data), true
+}
+
+func (b *Backend) Create(ctx context.Context, key string, data []byte) (string, error) {
+	if err := b.before(ctx, OpCreate, key); err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.objects[key]; ok {
+		return "", s3lock.ErrPreconditionFailed
+	}
+
+	return b.put(key, data), nil
+}
+
+func (b *Backend) Get(ctx context.Context, key string, ifMatch string) (*s3lock.Entry, error) {
+	if err := b.before(ctx, OpGet, key); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	obj, err := b.match(key, ifMatch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &s3lock.Entry{
+		Data:         slices.Clone(obj.data),
+		ETag:         obj.etag,
+		LastModified: obj.lastModified,
+	}
+
+	return entry, nil
+}
+
+func (b *Backend) Replace(ctx context.Context, key string, data []byte, ifMatch string) (string, error) {
+	if err := b.before(ctx, OpReplace, key); err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.match(key, ifMatch); err != nil {
+		return "", err
+	}
+
+	return b.put(key, data), nil
+}
+
+func (b *Backend) Delete(ctx context.Context, key string, ifMatch string) error {
+	if err := b.before(ctx, OpDelete, key); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.match(key, ifMatch); err != nil {
+		return err
+	}
+
+	delete(b.objects, key)
+
+	return nil
+}
+
+func (b *Backend) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := b.before(ctx, OpList, prefix); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	keys := []string{}
+
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return keys, nil
+}
+
+func (b *Backend) before(ctx context.Context, op Op, key string) error {
+	b.mu.Lock()
+	latency := b.latency
+	failure := b.failure
+	b.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if failure != nil {
+		return failure(op, key)
+	}
+
+	return nil
+}
+
+func (b *Backend) match(key string, ifMatch string) (*object, error) {
+	obj, ok := b.objects[key]
+
+	if !ok {
+		return nil, s3lock.ErrObjectNotFound
+	}
+
+	if ifMatch != "" && ifMatch != obj.etag {
+		return nil, s3lock.ErrPreconditionFailed
+	}
+
+	return obj, nil
+}
+
+func (b *Backend) put(key string, data []byte) string {
+	b.seq++
+
+	b.objects[key] = &object{
+		data:         slices.Clone(data),
+		etag:         fmt.Sprintf(`"%d"`, b.seq),
+		lastModified: time.Now(),
+	}
+
+	return b.objects[key].etag
+}
@@ -0,0 +1,116 @@
+package s3locktest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/s3locktest"
+)
+
+func TestBackend(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+
+	// Create
+	etag, err := backend.Create(t.Context(), "lock-obj", []byte("foo"))
+	require.NoError(t, err)
+	_, err = backend.Create(t.Context(), "lock-obj", []byte("bar"))
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+
+	// Get
+	entry, err := backend.Get(t.Context(), "lock-obj", etag)
+	require.NoError(t, err)
+	require.Equal(t, "foo", string(entry.Data))
+	_, err = backend.Get(t.Context(), "lock-obj", `"invalid"`)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	_, err = backend.Get(t.Context(), "not-found", "")
+	require.ErrorIs(t, err, s3lock.ErrObjectNotFound)
+
+	// Replace
+	etag2, err := backend.Replace(t.Context(), "lock-obj", []byte("bar"), etag)
+	require.NoError(t, err)
+	_, err = backend.Replace(t.Context(), "lock-obj", []byte("baz"), etag)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	data, ok := backend.Data("lock-obj")
+	require.True(t, ok)
+	require.Equal(t, "bar", string(data))
+
+	// List
+	backend.Put("locks/lock-obj2", []byte("baz"))
+	keys, err := backend.List(t.Context(), "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"lock-obj", "locks/lock-obj2"}, keys)
+
+	// Delete
+	err = backend.Delete(t.Context(), "lock-obj", etag)
+	require.ErrorIs(t, err, s3lock.ErrPreconditionFailed)
+	err = backend.Delete(t.Context(), "lock-obj", etag2)
+	require.NoError(t, err)
+	err = backend.Delete(t.Context(), "lock-obj", etag2)
+	require.ErrorIs(t, err, s3lock.ErrObjectNotFound)
+}
+
+func TestLock(t *testing.T) {
+	obj, backend := s3locktest.NewObject("lock-obj")
+
+	lock, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "mem://s3locktest/lock-obj", lock.String())
+	_, err = obj.Lock(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Removed by another process
+	backend.Put("lock-obj", []byte("other-id"))
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3lock.ErrLockMismatch)
+}
+
+func TestLatency(t *testing.T) {
+	obj, backend := s3locktest.NewObject("lock-obj")
+	backend.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	_, err := obj.Lock(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	_, ok := backend.Data("lock-obj")
+	require.False(t, ok)
+}
+
+func TestFailNext(t *testing.T) {
+	obj, backend := s3locktest.NewObject("lock-obj")
+	backend.FailNext(s3locktest.OpDelete, s3locktest.ErrInjected)
+
+	lock, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+
+	err = lock.Unlock()
+	require.ErrorIs(t, err, s3locktest.ErrInjected)
+
+	// Only the first deletion fails
+	err = lock.Unlock()
+	require.NoError(t, err)
+}
+
+func TestInjectFailure(t *testing.T) {
+	obj, backend := s3locktest.NewObject("lock-obj")
+	errThrottled := errors.New("throttled")
+
+	backend.InjectFailure(func(op s3locktest.Op, key string) error {
+		if op == s3locktest.OpCreate && key == "lock-obj" {
+			return errThrottled
+		}
+
+		return nil
+	})
+
+	_, err := obj.Lock(t.Context())
+	require.ErrorIs(t, err, errThrottled)
+
+	backend.InjectFailure(nil)
+	_, err = obj.Lock(t.Context())
+	require.NoError(t, err)
+}
@@ -0,0 +1,68 @@
+package s3lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Semaphore allows up to n holders at the same time.
+// Each holder locks one of the slot objects "<prefix>/0" to "<prefix>/<n-1>".
+type Semaphore struct {
+	obj   *Object
+	slots []*Object
+}
+
+func NewSemaphore(s3Client S3API, bucket string, prefix string, n int, opts ...Option) *Semaphore {
+	return NewSemaphoreWithBackend(newS3BackendWithOptions(s3Client, bucket, opts), prefix, n, opts...)
+}
+
+// NewSemaphoreWithBackend returns the semaphore of the prefix.
+// The options are the defaults of Acquire/AcquireWait.
+// Like make, it panics if n is less than 1.
+func NewSemaphoreWithBackend(backend Backend, prefix string, n int, opts ...Option) *Semaphore {
+	if n < 1 {
+		panic(fmt.Sprintf("s3lock: semaphore size out of range: %d", n))
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	sem := &Semaphore{
+		obj: NewWithBackend(backend, prefix, opts...),
+	}
+
+	for i := range n {
+		sem.slots = append(sem.slots, NewWithBackend(backend, fmt.Sprintf("%s/%d", prefix, i)))
+	}
+
+	return sem
+}
+
+// Acquire locks the first free slot.
+// The returned lock is released like a lock of Object.
+func (sem *Semaphore) Acquire(ctx context.Context, opts ...Option) (*Lock, error) {
+	return sem.acquire(ctx, sem.obj.options(opts))
+}
+
+func (sem *Semaphore) acquire(ctx context.Context, o *options) (*Lock, error) {
+	for _, slot := range sem.slots {
+		lock, err := slot.lock(ctx, o)
+
+		if err == nil {
+			return lock, nil
+		}
+
+		if !errors.Is(err, ErrLockAlreadyHeld) {
+			return nil, err
+		}
+	}
+
+	return nil, ErrLockAlreadyHeld
+}
+
+// AcquireWait waits for a free slot like Object.LockWait.
+// With WithFairQueue, the waiters are queued under "<prefix>.queue/".
+func (sem *Semaphore) AcquireWait(ctx context.Context, opts ...Option) (*Lock, error) {
+	return sem.obj.lockWait(ctx, sem.obj.options(opts), sem.acquire)
+}
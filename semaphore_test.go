@@ -0,0 +1,79 @@
+package s3lock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+	"github.com/winebarrel/s3lock/s3locktest"
+)
+
+func TestSemaphore(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "sem/0")
+	testDeleteObject(t, s3cli, "s3lock-test", "sem/1")
+
+	sem := s3lock.NewSemaphore(s3cli, "s3lock-test", "sem", 2)
+	lock1, err := sem.Acquire(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "s3://s3lock-test/sem/0", lock1.String())
+	lock2, err := sem.Acquire(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "s3://s3lock-test/sem/1", lock2.String())
+
+	// All slots are held
+	_, err = sem.Acquire(t.Context())
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	// Reuse the released slot
+	err = lock1.Unlock()
+	require.NoError(t, err)
+	lock3, err := sem.Acquire(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "s3://s3lock-test/sem/0", lock3.String())
+
+	err = lock2.Unlock()
+	require.NoError(t, err)
+	err = lock3.Unlock()
+	require.NoError(t, err)
+}
+
+func TestSemaphoreAcquireWait(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+	sem := s3lock.NewSemaphoreWithBackend(backend, "sem/", 1, s3lock.WithWaitInterval(10*time.Millisecond))
+	lock, err := sem.Acquire(t.Context())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	_, err = sem.AcquireWait(ctx)
+	require.ErrorIs(t, err, s3lock.ErrLockAlreadyHeld)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		lock.Unlock()
+	}()
+
+	lock2, err := sem.AcquireWait(t.Context(), s3lock.WithFairQueue(0))
+	require.NoError(t, err)
+	require.Equal(t, "sem/0", lock2.Key())
+
+	// The queue is kept under the prefix
+	keys, err := backend.List(t.Context(), "sem.queue/")
+	require.NoError(t, err)
+	require.Equal(t, []string{"sem.queue/counter"}, keys)
+}
+
+func TestSemaphoreInvalidSize(t *testing.T) {
+	backend := s3locktest.NewBackend("s3lock-test")
+
+	require.PanicsWithValue(t, "s3lock: semaphore size out of range: 0", func() {
+		s3lock.NewSemaphoreWithBackend(backend, "sem", 0)
+	})
+
+	require.PanicsWithValue(t, "s3lock: semaphore size out of range: -1", func() {
+		s3lock.NewSemaphore(testNewS3Client(t), "s3lock-test", "sem", -1)
+	})
+}
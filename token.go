@@ -0,0 +1,89 @@
+package s3lock
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const tokenKeySuffix = ".token"
+
+// Token returns the fencing token issued when the lock was acquired.
+// It returns 0 if the lock was acquired without WithFencingToken.
+func (l *Lock) Token() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.token
+}
+
+func (l *Lock) issueToken(ctx context.Context) error {
+	token, err := incrementCounter(ctx, l.backend, l.key+tokenKeySuffix)
+
+	if err != nil {
+		return err
+	}
+
+	// The token is valid only if the lock was not taken over while it was being issued.
+	// Otherwise a stale holder could get a larger token than the new holder.
+	if _, err := l.validate(ctx); err != nil {
+		if errors.Is(err, ErrLockMismatch) || errors.Is(err, ErrAlreadyUnlocked) {
+			return ErrLockAlreadyHeld
+		}
+
+		return err
+	}
+
+	l.token = token
+
+	return nil
+}
+
+func incrementCounter(ctx context.Context, backend Backend, key string) (uint64, error) {
+	for {
+		n, etag, err := getCounter(ctx, backend, key)
+
+		if err != nil {
+			return 0, err
+		}
+
+		data := []byte(strconv.FormatUint(n+1, 10))
+
+		if etag == "" {
+			_, err = backend.Create(ctx, key, data)
+		} else {
+			_, err = backend.Replace(ctx, key, data, etag)
+		}
+
+		if err == nil {
+			return n + 1, nil
+		}
+
+		if errors.Is(err, ErrObjectNotFound) || errors.Is(err, ErrPreconditionFailed) {
+			// updated by another client, retry
+			continue
+		}
+
+		return 0, err
+	}
+}
+
+func getCounter(ctx context.Context, backend Backend, key string) (uint64, string, error) {
+	entry, err := backend.Get(ctx, key, "")
+
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return 0, "", nil
+		}
+
+		return 0, "", err
+	}
+
+	n, err := strconv.ParseUint(strings.TrimSpace(string(entry.Data)), 10, 64)
+
+	if err != nil {
+		return 0, "", err
+	}
+
+	return n, entry.ETag, nil
+}
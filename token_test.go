@@ -0,0 +1,59 @@
+package s3lock_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/s3lock"
+)
+
+func TestToken(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj.token")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+	var tokens []uint64
+
+	for range 3 {
+		lock, err := obj.Lock(t.Context(), s3lock.WithFencingToken())
+		require.NoError(t, err)
+		tokens = append(tokens, lock.Token())
+
+		// The lock file keeps the token
+		j, err := json.Marshal(lock)
+		require.NoError(t, err)
+		lock, err = s3lock.NewLockFromJSON(s3cli, j)
+		require.NoError(t, err)
+		require.Equal(t, tokens[len(tokens)-1], lock.Token())
+
+		err = lock.Unlock()
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []uint64{1, 2, 3}, tokens)
+
+	// Confirm the counter object
+	body, err := testGetObject(t, s3cli, "s3lock-test", "lock-obj.token")
+	require.NoError(t, err)
+	require.Equal(t, "3", body)
+}
+
+func TestTokenWithoutFencing(t *testing.T) {
+	s3cli := testNewS3Client(t)
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj")
+	testDeleteObject(t, s3cli, "s3lock-test", "lock-obj.token")
+
+	obj := s3lock.New(s3cli, "s3lock-test", "lock-obj")
+	lock, err := obj.Lock(t.Context())
+	require.NoError(t, err)
+	require.Zero(t, lock.Token())
+
+	// No counter object is created
+	_, err = testGetObject(t, s3cli, "s3lock-test", "lock-obj.token")
+	require.ErrorContains(t, err, "The specified key does not exist")
+
+	err = lock.Unlock()
+	require.NoError(t, err)
+}